@@ -1,6 +1,7 @@
 package config
 
 // using driver yaml
+// file.Load decodes by mapstructure, field named differently from its key must have `mapstructure` tag too
 type Config struct {
 	Server ServerConfig   `yaml:"Server"`
 	DB     DBConfig       `yaml:"DB"`
@@ -10,12 +11,37 @@ type Config struct {
 
 type (
 	ServerConfig struct {
-		Port                    string `yaml:"Port"`
-		BasePath                string `yaml:"BasePath"`
-		GracefulTimeoutInSecond int    `yaml:"GracefulTimeout"`
-		ReadTimeoutInSecond     int    `yaml:"ReadTimeout"`
-		WriteTimeoutInSecond    int    `yaml:"WriteTimeout"`
-		APITimeout              int    `yaml:"APITimeout"`
+		Port                    string            `yaml:"Port"`
+		BasePath                string            `yaml:"BasePath"`
+		GracefulTimeoutInSecond int               `yaml:"GracefulTimeout" mapstructure:"GracefulTimeout"`
+		ReadTimeoutInSecond     int               `yaml:"ReadTimeout" mapstructure:"ReadTimeout"`
+		WriteTimeoutInSecond    int               `yaml:"WriteTimeout" mapstructure:"WriteTimeout"`
+		APITimeout              int               `yaml:"APITimeout"`
+		CORS                    CORSConfig        `yaml:"CORS"`
+		Maintenance             MaintenanceConfig `yaml:"Maintenance"`
+		// TrustedProxies are IPs or CIDRs of proxies allowed to tell client IP by forwarded headers
+		TrustedProxies []string `yaml:"TrustedProxies"`
+	}
+	CORSConfig struct {
+		AllowedOrigins   []string `yaml:"AllowedOrigins"`
+		AllowedMethods   []string `yaml:"AllowedMethods"`
+		AllowedHeaders   []string `yaml:"AllowedHeaders"`
+		ExposedHeaders   []string `yaml:"ExposedHeaders"`
+		AllowCredentials bool     `yaml:"AllowCredentials"`
+		MaxAge           int      `yaml:"MaxAge"`
+		// Routes overrides CORS for routes under the path prefix, example: `/public`
+		Routes map[string]CORSConfig `yaml:"Routes"`
+	}
+	MaintenanceConfig struct {
+		Enabled            bool `yaml:"Enabled"`
+		RetryAfterInSecond int  `yaml:"RetryAfter" mapstructure:"RetryAfter"`
+		// AllowedPaths are still served during maintenance, path ending with `/*` allows everything under it
+		AllowedPaths []string `yaml:"AllowedPaths"`
+		// BypassIPs are IPs or CIDRs still served during maintenance
+		BypassIPs []string `yaml:"BypassIPs"`
+		// Start and End are scheduled maintenance window in RFC3339, example: `2024-01-02T15:04:05Z`
+		Start string `yaml:"Start"`
+		End   string `yaml:"End"`
 	}
 	DBConfig struct {
 		RetryInterval int    `yaml:"RetryInterval"`
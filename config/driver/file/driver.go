@@ -1,6 +1,9 @@
 package file
 
-import "github.com/spf13/viper"
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
 
 type option struct {
 	filePath []string
@@ -73,3 +76,16 @@ func Load(cfg interface{}, opts ...Option) (err error) {
 	err = viper.Unmarshal(cfg)
 	return
 }
+
+// Watch watches config file read by Load, and calls onChange with newly read config on every change,
+// example: to toggle maintenance mode without restarting the app.
+// Keep current config when err is not nil, since the file may be half-written.
+func Watch[T any](onChange func(cfg *T, err error)) {
+	viper.OnConfigChange(func(fsnotify.Event) {
+		cfg := new(T)
+		err := viper.Unmarshal(cfg)
+		onChange(cfg, err)
+	})
+
+	viper.WatchConfig()
+}
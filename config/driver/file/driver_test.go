@@ -0,0 +1,45 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/dee-el/go-fw/config"
+)
+
+func TestLoadExample(t *testing.T) {
+	var cfg config.Config
+	err := Load(&cfg, WithFilePath([]string{"../../files"}), WithFileName("config.example"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"GracefulTimeout", cfg.Server.GracefulTimeoutInSecond, 10},
+		{"ReadTimeout", cfg.Server.ReadTimeoutInSecond, 10},
+		{"WriteTimeout", cfg.Server.WriteTimeoutInSecond, 10},
+		{"APITimeout", cfg.Server.APITimeout, 10},
+		{"Maintenance.RetryAfter", cfg.Server.Maintenance.RetryAfterInSecond, 300},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if got := cfg.Server.Maintenance.AllowedPaths; len(got) != 2 || got[1] != "/admin/*" {
+		t.Errorf("Maintenance.AllowedPaths = %v, want [/health /admin/*]", got)
+	}
+
+	if got := cfg.Server.TrustedProxies; len(got) != 1 || got[0] != "10.0.0.0/8" {
+		t.Errorf("TrustedProxies = %v, want [10.0.0.0/8]", got)
+	}
+
+	if cfg.Server.Port != ":8080" {
+		t.Errorf("Port = %q, want :8080", cfg.Server.Port)
+	}
+}
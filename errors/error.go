@@ -1,5 +1,10 @@
 package errors
 
+import (
+	"encoding/xml"
+	"sort"
+)
+
 type Code int
 
 type Type string
@@ -19,14 +24,58 @@ type Type string
 // or whatever user want, this thing will never be exhausted because user can craete their own combination Type and Code.
 // Another reason is easier to tracking flow of business.
 type Error struct {
-	Type    Type   `json:"type"`
-	Code    Code   `json:"code"`
-	Message string `json:"message"`
-	Fields  Fields `json:"fields"`
+	Type    Type   `json:"type" xml:"type"`
+	Code    Code   `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
+	Fields  Fields `json:"fields" xml:"fields,omitempty"`
 }
 
 type Fields map[string]string
 
+type xmlField struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML encodes Fields as `<field name="key">value</field>` elements, since XML does not support map.
+func (f Fields) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if len(f) == 0 {
+		return nil
+	}
+
+	fields := struct {
+		Field []xmlField `xml:"field"`
+	}{}
+	for k, v := range f {
+		fields.Field = append(fields.Field, xmlField{Name: k, Value: v})
+	}
+	// keep output stable
+	sort.Slice(fields.Field, func(i, j int) bool {
+		return fields.Field[i].Name < fields.Field[j].Name
+	})
+
+	return e.EncodeElement(fields, start)
+}
+
+// UnmarshalXML decodes Fields encoded by MarshalXML.
+func (f *Fields) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	fields := struct {
+		Field []xmlField `xml:"field"`
+	}{}
+
+	err := d.DecodeElement(&fields, &start)
+	if err != nil {
+		return err
+	}
+
+	*f = Fields{}
+	for _, field := range fields.Field {
+		(*f)[field.Name] = field.Value
+	}
+
+	return nil
+}
+
 func (e *Error) AddField(k, v string) {
 	if len(e.Fields) == 0 {
 		e.Fields = map[string]string{}
@@ -35,6 +84,17 @@ func (e *Error) AddField(k, v string) {
 	e.Fields[k] = v
 }
 
+// Copy returns new Error with same Type, Code, Message and Fields.
+// Use this before AddField on reserved errors, so they are not mutated.
+func (e *Error) Copy() *Error {
+	cp := New(e.Type, e.Code, e.Message)
+	for k, v := range e.Fields {
+		cp.AddField(k, v)
+	}
+
+	return cp
+}
+
 // Error satisfying interface Error, just return the message
 func (e *Error) Error() string {
 	return e.Message
@@ -56,13 +116,19 @@ const (
 
 	// 8xx
 	Code805 = 805
+	Code806 = 806
 	Code822 = 822
 	Code825 = 825
 	Code831 = 831
+	Code841 = 841
+	Code842 = 842
+	Code843 = 843
+	Code844 = 844
 
 	// 9xx
 	Code901 = 901
 	Code910 = 910
+	Code911 = 911
 )
 
 // Reserved types
@@ -74,16 +140,27 @@ const (
 	TypeApplicationLimitError Type = "ApplicationLimitError" // throttle
 	TypeMaintenanceError      Type = "MaintenanceError"
 	TypeBadRequestError       Type = "BadRequestError"
+	TypeNotAcceptableError    Type = "NotAcceptableError"    // no acceptable response format
+	TypeUnsupportedMediaError Type = "UnsupportedMediaError" // request body format is not supported
+	TypePayloadTooLargeError  Type = "PayloadTooLargeError"
+	TypeClientClosedError     Type = "ClientClosedError" // client gave up before response is written
+	TypeTimeoutError          Type = "TimeoutError"      // request took longer than its deadline
 )
 
 // Reserved errors
 // any other business should be added on its own error dictionary
 var (
 	ErrorAuthentication   = New(TypeAuthenticationError, Code805, "Authentication failed")
+	ErrorTokenExpired     = New(TypeAuthenticationError, Code806, "Access token is expired")
 	ErrorNotFound         = New(TypeNotFoundError, Code822, "Resource not found")
 	ErrorForbidden        = New(TypeForbiddenError, Code825, "Permission denied")
 	ErrorInternalServer   = New(TypeInternalServerError, Code901, "Oops, something went wrong")
 	ErrorMaintenance      = New(TypeMaintenanceError, Code910, "Sorry, app is under maintenance")
 	ErrorApplicationLimit = New(TypeApplicationLimitError, Code831, "Application limit is exceeded")
 	ErrorBadRequest       = New(TypeBadRequestError, Code101, "Bad request")
+	ErrorNotAcceptable    = New(TypeNotAcceptableError, Code841, "Requested response format is not supported")
+	ErrorUnsupportedMedia = New(TypeUnsupportedMediaError, Code842, "Request body format is not supported")
+	ErrorPayloadTooLarge  = New(TypePayloadTooLargeError, Code843, "Request body is too large")
+	ErrorClientClosed     = New(TypeClientClosedError, Code844, "Request is canceled by client")
+	ErrorTimeout          = New(TypeTimeoutError, Code911, "Request took too long to process")
 )
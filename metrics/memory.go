@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryRecorder is Recorder which keeps everything in memory, mostly used to assert metrics on tests.
+type MemoryRecorder struct {
+	mu           sync.RWMutex
+	counters     map[string]float64
+	gauges       map[string]float64
+	observations map[string][]float64
+}
+
+func NewMemoryRecorder() *MemoryRecorder {
+	m := &MemoryRecorder{}
+	m.Reset()
+
+	return m
+}
+
+func (m *MemoryRecorder) Add(name string, value float64, labels Labels) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[key(name, labels)] += value
+}
+
+func (m *MemoryRecorder) Set(name string, value float64, labels Labels) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.gauges[key(name, labels)] = value
+}
+
+func (m *MemoryRecorder) Observe(name string, value float64, labels Labels) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := key(name, labels)
+	m.observations[k] = append(m.observations[k], value)
+}
+
+// Counter returns current value of counter, 0 when it is never recorded.
+func (m *MemoryRecorder) Counter(name string, labels Labels) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.counters[key(name, labels)]
+}
+
+// Gauge returns last value of gauge, false when it is never recorded.
+func (m *MemoryRecorder) Gauge(name string, labels Labels) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v, ok := m.gauges[key(name, labels)]
+	return v, ok
+}
+
+// Observations returns copy of every value observed by histogram, in order.
+func (m *MemoryRecorder) Observations(name string, labels Labels) []float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	obs := m.observations[key(name, labels)]
+	cp := make([]float64, len(obs))
+	copy(cp, obs)
+
+	return cp
+}
+
+// Reset removes everything recorded.
+func (m *MemoryRecorder) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters = map[string]float64{}
+	m.gauges = map[string]float64{}
+	m.observations = map[string][]float64{}
+}
+
+// key returns `name{k1="v1",k2="v2"}` with sorted label names, so order of labels does not matter.
+func key(name string, labels Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteString("{")
+	for i, k := range names {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(k + `="` + labels[k] + `"`)
+	}
+	sb.WriteString("}")
+
+	return sb.String()
+}
@@ -0,0 +1,9 @@
+package metrics
+
+import "time"
+
+// OutboundHTTPRecorder is metric recorder for every outbound traffics to other HTTP services.
+// Route is the URL template (example: `/users/{id}`), not the real path, to keep cardinality low.
+type OutboundHTTPRecorder interface {
+	Record(host, route, method, status string, duration time.Duration)
+}
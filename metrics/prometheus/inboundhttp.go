@@ -0,0 +1,101 @@
+package prometheus
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// InboundHTTPRecorder implements metrics.InboundHTTPRecorder using Prometheus.
+// It records:
+//   - `http_server_requests_total` counter
+//   - `http_server_request_duration_seconds` histogram
+//
+// both are labeled by `path`, `method` and `status`.
+type InboundHTTPRecorder struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	gatherer prometheus.Gatherer
+}
+
+type config struct {
+	namespace  string
+	buckets    []float64
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+}
+
+type Option func(c *config)
+
+// WithNamespace is an option to prefix metric names, example: `myapp` makes `myapp_http_server_requests_total`.
+func WithNamespace(ns string) Option {
+	return func(c *config) {
+		c.namespace = ns
+	}
+}
+
+// WithBuckets is an option to set histogram buckets in seconds. Default is prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithRegistry is an option to register metrics on reg instead of new dedicated registry,
+// example: prometheus.DefaultRegisterer to expose them with Go runtime metrics.
+func WithRegistry(reg interface {
+	prometheus.Registerer
+	prometheus.Gatherer
+}) Option {
+	return func(c *config) {
+		c.registerer = reg
+		c.gatherer = reg
+	}
+}
+
+// NewInboundHTTPRecorder returns recorder with its metrics registered.
+// It panics when metrics with the same name already registered on the registry.
+func NewInboundHTTPRecorder(opts ...Option) *InboundHTTPRecorder {
+	reg := prometheus.NewRegistry()
+	c := &config{
+		buckets:    prometheus.DefBuckets,
+		registerer: reg,
+		gatherer:   reg,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	labels := []string{"path", "method", "status"}
+	rec := &InboundHTTPRecorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "http_server_requests_total",
+			Help:      "Total number of inbound HTTP requests.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: c.namespace,
+			Name:      "http_server_request_duration_seconds",
+			Help:      "Latency of inbound HTTP requests in seconds.",
+			Buckets:   c.buckets,
+		}, labels),
+		gatherer: c.gatherer,
+	}
+
+	c.registerer.MustRegister(rec.requests, rec.duration)
+	return rec
+}
+
+func (rec *InboundHTTPRecorder) Record(path, method, status string, duration time.Duration) {
+	rec.requests.WithLabelValues(path, method, status).Inc()
+	rec.duration.WithLabelValues(path, method, status).Observe(duration.Seconds())
+}
+
+// Handler returns http.Handler exposing metrics in Prometheus text exposition format.
+// Mount it on server, example: `s.MethodFunc(http.MethodGet, "/metrics", rec.Handler())`.
+func (rec *InboundHTTPRecorder) Handler() http.Handler {
+	return promhttp.HandlerFor(rec.gatherer, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Labels are dimensions of a metric, example: `{"method": "GET"}`.
+type Labels map[string]string
+
+// CounterRecorder records value which only goes up, example: total requests.
+type CounterRecorder interface {
+	Add(name string, value float64, labels Labels)
+}
+
+// GaugeRecorder records value which goes up and down, example: open connections.
+type GaugeRecorder interface {
+	Set(name string, value float64, labels Labels)
+}
+
+// HistogramRecorder records distribution of values, example: latency.
+type HistogramRecorder interface {
+	Observe(name string, value float64, labels Labels)
+}
+
+// Recorder is a family of all recorders above, so one backend can be passed around.
+type Recorder interface {
+	CounterRecorder
+	GaugeRecorder
+	HistogramRecorder
+}
+
+type inboundHTTP struct {
+	rec Recorder
+}
+
+// InboundHTTPFromRecorder returns InboundHTTPRecorder which records into rec as
+// `http_server_requests_total` counter and `http_server_request_duration_seconds` histogram.
+func InboundHTTPFromRecorder(rec Recorder) InboundHTTPRecorder {
+	return &inboundHTTP{rec: rec}
+}
+
+func (i *inboundHTTP) Record(path, method, status string, duration time.Duration) {
+	labels := Labels{"path": path, "method": method, "status": status}
+	i.rec.Add("http_server_requests_total", 1, labels)
+	i.rec.Observe("http_server_request_duration_seconds", duration.Seconds(), labels)
+}
+
+type outboundHTTP struct {
+	rec Recorder
+}
+
+// OutboundHTTPFromRecorder returns OutboundHTTPRecorder which records into rec as
+// `http_client_requests_total` counter and `http_client_request_duration_seconds` histogram.
+func OutboundHTTPFromRecorder(rec Recorder) OutboundHTTPRecorder {
+	return &outboundHTTP{rec: rec}
+}
+
+func (o *outboundHTTP) Record(host, route, method, status string, duration time.Duration) {
+	labels := Labels{"host": host, "route": route, "method": method, "status": status}
+	o.rec.Add("http_client_requests_total", 1, labels)
+	o.rec.Observe("http_client_request_duration_seconds", duration.Seconds(), labels)
+}
+
+// RecordDBStats records connection pool stats of db as gauges labeled by `db`.
+// Call it periodically, example: every 15 seconds from a ticker.
+func RecordDBStats(rec GaugeRecorder, db string, stats sql.DBStats) {
+	labels := Labels{"db": db}
+	rec.Set("db_max_open_connections", float64(stats.MaxOpenConnections), labels)
+	rec.Set("db_open_connections", float64(stats.OpenConnections), labels)
+	rec.Set("db_in_use_connections", float64(stats.InUse), labels)
+	rec.Set("db_idle_connections", float64(stats.Idle), labels)
+	rec.Set("db_wait_count", float64(stats.WaitCount), labels)
+	rec.Set("db_wait_duration_seconds", stats.WaitDuration.Seconds(), labels)
+}
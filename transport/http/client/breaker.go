@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when request is rejected because circuit breaker of its host is open.
+var ErrCircuitOpen = errors.New("client: circuit breaker is open")
+
+// BreakerConfig configures circuit breaker of each host.
+type BreakerConfig struct {
+	// FailureThreshold is how many consecutive failures open the circuit.
+	FailureThreshold int
+	// OpenTimeout is how long circuit stays open before trying again (half-open).
+	OpenTimeout time.Duration
+	// HalfOpenRequests is how many requests allowed while half-open, all of them must succeed to close the circuit.
+	HalfOpenRequests int
+	// IsFailure decides whether result counts as failure, default is transport error or 5xx status.
+	IsFailure func(resp *http.Response, err error) bool
+}
+
+// DefaultBreakerConfig opens circuit after 5 consecutive failures for 30 seconds.
+var DefaultBreakerConfig = BreakerConfig{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+	HalfOpenRequests: 1,
+}
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+type breaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	inFlight  int
+	successes int
+}
+
+// CircuitBreaker is http.RoundTripper which keeps one circuit breaker per host,
+// so one unhealthy downstream does not make the caller keep waiting on it.
+type CircuitBreaker struct {
+	next   http.RoundTripper
+	config BreakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewCircuitBreaker returns CircuitBreaker wrapping next, http.DefaultTransport is used when next is nil.
+func NewCircuitBreaker(config BreakerConfig, next http.RoundTripper) *CircuitBreaker {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultBreakerConfig.FailureThreshold
+	}
+
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DefaultBreakerConfig.OpenTimeout
+	}
+
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = DefaultBreakerConfig.HalfOpenRequests
+	}
+
+	if config.IsFailure == nil {
+		config.IsFailure = func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+
+	return &CircuitBreaker{
+		next:     next,
+		config:   config,
+		breakers: map[string]*breaker{},
+	}
+}
+
+func (cb *CircuitBreaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	b := cb.breaker(host)
+
+	if !cb.allow(b) {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+	}
+
+	resp, err := cb.next.RoundTrip(req)
+
+	// caller canceled, it says nothing about the host health, unlike deadline exceeded which means host is slow
+	if err != nil && errors.Is(req.Context().Err(), context.Canceled) {
+		cb.release(b)
+		return resp, err
+	}
+
+	cb.record(b, !cb.config.IsFailure(resp, err))
+	return resp, err
+}
+
+func (cb *CircuitBreaker) breaker(host string) *breaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, ok := cb.breakers[host]
+	if !ok {
+		b = &breaker{}
+		cb.breakers[host] = b
+	}
+
+	return b
+}
+
+func (cb *CircuitBreaker) allow(b *breaker) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < cb.config.OpenTimeout {
+			return false
+		}
+
+		b.state = stateHalfOpen
+		b.inFlight = 0
+		b.successes = 0
+		fallthrough
+	case stateHalfOpen:
+		if b.inFlight >= cb.config.HalfOpenRequests {
+			return false
+		}
+		b.inFlight++
+	}
+
+	return true
+}
+
+func (cb *CircuitBreaker) release(b *breaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == stateHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+}
+
+func (cb *CircuitBreaker) record(b *breaker, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+
+		b.failures++
+		if b.failures >= cb.config.FailureThreshold {
+			b.state = stateOpen
+			b.openedAt = time.Now()
+		}
+	case stateHalfOpen:
+		if !success {
+			b.state = stateOpen
+			b.openedAt = time.Now()
+			return
+		}
+
+		b.successes++
+		if b.successes >= cb.config.HalfOpenRequests {
+			b.state = stateClosed
+			b.failures = 0
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is adapter to use ordinary function as http.RoundTripper.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// statusTransport responds with status, counting every request it receives.
+type statusTransport struct {
+	status int
+	calls  int
+}
+
+func (t *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return &http.Response{StatusCode: t.status, Body: http.NoBody, Header: http.Header{}, Request: req}, nil
+}
+
+func send(rt http.RoundTripper, url string) error {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	_, err := rt.RoundTrip(req)
+	return err
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	next := &statusTransport{status: http.StatusInternalServerError}
+	cb := NewCircuitBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour}, next)
+
+	// success in between resets the failures
+	send(cb, "http://a/")
+	next.status = http.StatusOK
+	send(cb, "http://a/")
+	next.status = http.StatusInternalServerError
+	send(cb, "http://a/")
+	if err := send(cb, "http://a/"); err != nil {
+		t.Fatalf("want circuit closed until 2 consecutive failures, got %v", err)
+	}
+
+	calls := next.calls
+	if err := send(cb, "http://a/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if next.calls != calls {
+		t.Error("request is sent while circuit is open")
+	}
+
+	// every host has its own breaker
+	if err := send(cb, "http://b/"); err != nil {
+		t.Errorf("other host: err = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantClosed bool
+	}{
+		{"success closes", http.StatusOK, true},
+		{"failure reopens", http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &statusTransport{status: http.StatusInternalServerError}
+			cb := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond}, next)
+
+			send(cb, "http://a/")
+			if err := send(cb, "http://a/"); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("err = %v, want ErrCircuitOpen", err)
+			}
+
+			time.Sleep(30 * time.Millisecond)
+			next.status = tt.status
+			if err := send(cb, "http://a/"); err != nil {
+				t.Fatalf("half-open request: err = %v, want nil", err)
+			}
+
+			err := send(cb, "http://a/")
+			if closed := err == nil; closed != tt.wantClosed {
+				t.Errorf("err = %v, want closed %v", err, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenLimitsRequests(t *testing.T) {
+	cb := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Millisecond, HalfOpenRequests: 2}, nil)
+	b := cb.breaker("a")
+	cb.record(b, false)
+
+	time.Sleep(5 * time.Millisecond)
+	if !cb.allow(b) || !cb.allow(b) {
+		t.Fatal("want 2 requests allowed while half-open")
+	}
+	if cb.allow(b) {
+		t.Fatal("want third request rejected while half-open")
+	}
+
+	// canceled one gives its slot back
+	cb.release(b)
+	if !cb.allow(b) {
+		t.Error("want request allowed after one is released")
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledRequests(t *testing.T) {
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, req.Context().Err()
+	})
+	cb := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour}, next)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://a/", nil)
+		if _, err := cb.RoundTrip(req); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d: circuit is opened by canceled request", i)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dee-el/go-fw/metrics"
+)
+
+type config struct {
+	transport   http.RoundTripper
+	timeout     time.Duration
+	recorder    metrics.OutboundHTTPRecorder
+	tracingOpts []TracingOption
+	retry       *RetryPolicy
+	breaker     *BreakerConfig
+}
+
+type Option func(c *config)
+
+// WithTransport is an option to set base transport, default is http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = rt
+	}
+}
+
+// WithTimeout is an option to limit whole request time, including reading response body.
+func WithTimeout(tm time.Duration) Option {
+	return func(c *config) {
+		c.timeout = tm
+	}
+}
+
+// WithMetrics is an option to record every request, see Metrics.
+func WithMetrics(recorder metrics.OutboundHTTPRecorder) Option {
+	return func(c *config) {
+		c.recorder = recorder
+	}
+}
+
+// WithTracing is an option to trace every request, see Tracing.
+func WithTracing(opts ...TracingOption) Option {
+	return func(c *config) {
+		c.tracingOpts = append(c.tracingOpts, opts...)
+	}
+}
+
+// WithRetry is an option to retry failed requests, see Retry.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = &policy
+	}
+}
+
+// WithCircuitBreaker is an option to guard every host by circuit breaker, see CircuitBreaker.
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(c *config) {
+		c.breaker = &cfg
+	}
+}
+
+// New returns http.Client for calling other services.
+// Request ID of inbound request is always forwarded, see RequestID.
+//
+// Example:
+//
+//	c := client.New(
+//		client.WithTimeout(5*time.Second),
+//		client.WithTracing(client.OTelTracingOption(otel.GetTracerProvider())),
+//		client.WithRetry(client.DefaultRetryPolicy),
+//		client.WithCircuitBreaker(client.DefaultBreakerConfig),
+//	)
+//	req, _ := http.NewRequestWithContext(client.WithRoute(ctx, "/users/{id}"), http.MethodGet, url, nil)
+//	err := client.Do(c, req, &user)
+func New(opts ...Option) *http.Client {
+	c := &config{
+		transport: http.DefaultTransport,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return &http.Client{
+		Transport: c.roundTripper(),
+		Timeout:   c.timeout,
+	}
+}
+
+// roundTripper chains transports, the outermost is the first one called:
+// request ID -> retry -> circuit breaker -> tracing -> metrics -> base transport.
+// So every attempt has its own span and metric.
+func (c *config) roundTripper() http.RoundTripper {
+	rt := c.transport
+
+	if c.recorder != nil {
+		rt = NewMetrics(c.recorder, rt)
+	}
+
+	if len(c.tracingOpts) > 0 {
+		rt = NewTracing(rt, c.tracingOpts...)
+	}
+
+	if c.breaker != nil {
+		rt = NewCircuitBreaker(*c.breaker, rt)
+	}
+
+	if c.retry != nil {
+		rt = NewRetry(*c.retry, rt)
+	}
+
+	return NewRequestID(rt)
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dee-el/go-fw/metrics"
+)
+
+// Metrics is http.RoundTripper which records every outgoing request.
+type Metrics struct {
+	next http.RoundTripper
+	// all metrics will be recorded by this.
+	recorder metrics.OutboundHTTPRecorder
+	// same as middleware.Metrics, status will be grouped in the form of `\dxx`.
+	groupedStatus bool
+}
+
+// NewMetrics returns Metrics wrapping next, http.DefaultTransport is used when next is nil.
+//
+// Example:
+//
+//	c := &http.Client{Transport: client.NewMetrics(recorder, nil)}
+func NewMetrics(recorder metrics.OutboundHTTPRecorder, next http.RoundTripper, opts ...MetricsOption) *Metrics {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	m := &Metrics{
+		next:     next,
+		recorder: recorder,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+type MetricsOption func(*Metrics)
+
+func GroupedStatusMetricsOption(groupedStatus bool) MetricsOption {
+	return func(m *Metrics) {
+		m.groupedStatus = groupedStatus
+	}
+}
+
+func (m *Metrics) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := time.Now()
+	resp, err := m.next.RoundTrip(req)
+	duration := time.Since(now)
+
+	// same as inbound, use the template when it is set, never the real path
+	route := routeOf(req.Context())
+
+	// no response at all, example: connection refused or timeout
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+		if m.groupedStatus {
+			code = fmt.Sprintf("%dxx", resp.StatusCode/100)
+		}
+	}
+
+	m.recorder.Record(req.URL.Host, route, req.Method, code, duration)
+	return resp, err
+}
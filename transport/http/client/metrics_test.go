@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type routeRecorder struct {
+	routes []string
+}
+
+func (r *routeRecorder) Record(host, route, method, status string, duration time.Duration) {
+	r.routes = append(r.routes, route)
+}
+
+func TestMetricsRoute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"template", WithRoute(context.Background(), "/users/{id}"), "/users/{id}"},
+		{"without route", context.Background(), UnknownRoute},
+		{"empty route", WithRoute(context.Background(), ""), UnknownRoute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &routeRecorder{}
+			c := &http.Client{Transport: NewMetrics(rec, nil)}
+
+			req, _ := http.NewRequestWithContext(tt.ctx, http.MethodGet, srv.URL+"/users/42", nil)
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if len(rec.routes) != 1 || rec.routes[0] != tt.want {
+				t.Errorf("routes = %v, want [%s]", rec.routes, tt.want)
+			}
+		})
+	}
+}
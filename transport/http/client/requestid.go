@@ -0,0 +1,35 @@
+package client
+
+import (
+	"net/http"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestID is http.RoundTripper which forwards request ID of inbound request (set by chi RequestID middleware)
+// to outgoing request header, so one call chain across services can be stitched by the same ID.
+type RequestID struct {
+	next http.RoundTripper
+}
+
+// NewRequestID returns RequestID wrapping next, http.DefaultTransport is used when next is nil.
+func NewRequestID(next http.RoundTripper) *RequestID {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &RequestID{next: next}
+}
+
+func (rid *RequestID) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := chi_middleware.GetReqID(req.Context())
+	// keep the one explicitly set by user
+	if id == "" || req.Header.Get(chi_middleware.RequestIDHeader) != "" {
+		return rid.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(chi_middleware.RequestIDHeader, id)
+
+	return rid.next.RoundTrip(req)
+}
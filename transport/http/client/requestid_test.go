@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctxID  string
+		header string
+		want   string
+	}{
+		{"forwarded", "req-1", "", "req-1"},
+		{"set by caller is kept", "req-1", "mine", "mine"},
+		{"without inbound request", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, headers := headerServer(t, http.StatusOK)
+
+			ctx := context.Background()
+			if tt.ctxID != "" {
+				ctx = context.WithValue(ctx, chi_middleware.RequestIDKey, tt.ctxID)
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			if tt.header != "" {
+				req.Header.Set(chi_middleware.RequestIDHeader, tt.header)
+			}
+
+			resp, err := (&http.Client{Transport: NewRequestID(nil)}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got := (*headers)[0].Get(chi_middleware.RequestIDHeader); got != tt.want {
+				t.Errorf("%s = %q, want %q", chi_middleware.RequestIDHeader, got, tt.want)
+			}
+			if got := req.Header.Get(chi_middleware.RequestIDHeader); got != tt.header {
+				t.Errorf("caller request header = %q, want it untouched", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// StatusError is returned by DecodeResponse when response fails but does not carry standard error envelope,
+// example: 502 from load balancer.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("client: unexpected status %d", e.StatusCode)
+}
+
+// envelope mirrors response.Response, but keeps Data raw so it can be decoded into user type.
+type envelope struct {
+	Error *errors.Error   `json:"error"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// DecodeResponse decodes response sent by service built on this framework, then closes its body.
+// The `data` is decoded into data (can be nil to ignore it), while `error` is returned as *errors.Error as is,
+// so business errors round-trip between services. Response without body, example: 204 or HEAD, leaves data as is.
+func DecodeResponse(resp *http.Response, data interface{}) error {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return &StatusError{StatusCode: resp.StatusCode, Body: body}
+		}
+		return nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return &StatusError{StatusCode: resp.StatusCode, Body: body}
+		}
+		return err
+	}
+
+	if env.Error != nil {
+		return env.Error
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &StatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+
+	if data == nil || len(env.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(env.Data, data)
+}
+
+// Do sends req using c, then decodes its response using DecodeResponse.
+//
+// Example:
+//
+//	var user User
+//	err := client.Do(c, req, &user)
+//	if e, ok := err.(*errors.Error); ok && e.Type == errors.TypeNotFoundError {
+//		...
+//	}
+func Do(c *http.Client, req *http.Request, data interface{}) error {
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+
+	return DecodeResponse(resp, data)
+}
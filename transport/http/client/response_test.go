@@ -0,0 +1,63 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantName string
+		wantErr  func(err error) bool
+	}{
+		{"data", http.StatusOK, `{"error":null,"data":{"name":"a"}}`, "a", nil},
+		{"no content", http.StatusNoContent, ``, "", nil},
+		{"not modified", http.StatusNotModified, ``, "", nil},
+		{"empty 2xx", http.StatusAccepted, ``, "", nil},
+		{"blank 2xx", http.StatusOK, "\n", "", nil},
+		{"business error", http.StatusNotFound, `{"error":{"type":"NotFoundError","code":822,"message":"x"},"data":null}`, "", func(err error) bool {
+			e, ok := err.(*errors.Error)
+			return ok && e.Code == errors.Code822
+		}},
+		{"empty error", http.StatusBadGateway, ``, "", func(err error) bool {
+			e, ok := err.(*StatusError)
+			return ok && e.StatusCode == http.StatusBadGateway
+		}},
+		{"non envelope error", http.StatusBadGateway, `<html>bad gateway</html>`, "", func(err error) bool {
+			_, ok := err.(*StatusError)
+			return ok
+		}},
+		{"malformed 2xx", http.StatusOK, `{`, "", func(err error) bool { return err != nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))}
+
+			var u user
+			err := DecodeResponse(resp, &u)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if tt.wantErr != nil && !tt.wantErr(err) {
+				t.Fatalf("unexpected err: %#v", err)
+			}
+
+			if u.Name != tt.wantName {
+				t.Fatalf("name = %q, want %q", u.Name, tt.wantName)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides when and how long to wait before retrying a request.
+type RetryPolicy struct {
+	// MaxAttempts including the first one, 1 or less means no retry.
+	MaxAttempts int
+	// BaseDelay is the delay before first retry, doubled on every next retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay, including the one from `Retry-After` header.
+	MaxDelay time.Duration
+	// Jitter randomizes delay between 0 and the computed delay (full jitter), to avoid retry storms.
+	Jitter bool
+	// RetryStatuses are response statuses which should be retried.
+	RetryStatuses []int
+	// RetryNonIdempotent allows retrying POST and PATCH too, only enable it when the endpoint is safe to repeat.
+	RetryNonIdempotent bool
+	// PerAttemptTimeout limits each attempt, while the whole call is still limited by request context.
+	PerAttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy retries idempotent requests 3 times at most on 429, 502, 503 and 504,
+// with exponential backoff starting from 100ms up to 5s, with jitter.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   3,
+	BaseDelay:     100 * time.Millisecond,
+	MaxDelay:      5 * time.Second,
+	Jitter:        true,
+	RetryStatuses: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// Retry is http.RoundTripper which retries failed requests based on RetryPolicy.
+// Request with body is only retried when its GetBody is set, which is done by http.NewRequest for common body types.
+type Retry struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// NewRetry returns Retry wrapping next, http.DefaultTransport is used when next is nil.
+func NewRetry(policy RetryPolicy, next http.RoundTripper) *Retry {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Retry{
+		next:   next,
+		policy: policy,
+	}
+}
+
+func (rt *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	canRetry := rt.policy.MaxAttempts > 1 && rt.isRetryableMethod(req.Method) &&
+		(req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := rt.attempt(attemptReq)
+		if !canRetry || attempt >= rt.policy.MaxAttempts || !rt.shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := rt.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+				if rt.policy.MaxDelay > 0 && delay > rt.policy.MaxDelay {
+					delay = rt.policy.MaxDelay
+				}
+			}
+		}
+
+		// do not wait when the caller can not wait that long, just give the last result back
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+
+		if resp != nil {
+			// let connection be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends one request, limited by PerAttemptTimeout.
+func (rt *Retry) attempt(req *http.Request) (*http.Response, error) {
+	if rt.policy.PerAttemptTimeout <= 0 {
+		return rt.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), rt.policy.PerAttemptTimeout)
+	resp, err := rt.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// body is still read after this returns, so cancel it only when body is closed
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (rt *Retry) isRetryableMethod(method string) bool {
+	if rt.policy.RetryNonIdempotent {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func (rt *Retry) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// caller gave up, or the downstream is known to be unhealthy
+		if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+			return false
+		}
+
+		return true
+	}
+
+	for _, status := range rt.policy.RetryStatuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rt *Retry) backoff(attempt int) time.Duration {
+	delay := time.Duration(float64(rt.policy.BaseDelay) * math.Pow(2, float64(attempt-1)))
+	if rt.policy.MaxDelay > 0 && delay > rt.policy.MaxDelay {
+		delay = rt.policy.MaxDelay
+	}
+
+	if rt.policy.Jitter && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+
+	return delay
+}
+
+// parseRetryAfter supports both delay in seconds and HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		delay := time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
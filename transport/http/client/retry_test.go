@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:   3,
+		BaseDelay:     time.Millisecond,
+		RetryStatuses: []int{http.StatusServiceUnavailable},
+	}
+
+	tests := []struct {
+		name      string
+		policy    RetryPolicy
+		method    string
+		status    int
+		wantCalls int
+	}{
+		{"retryable status", policy, http.MethodGet, http.StatusServiceUnavailable, 3},
+		{"other status", policy, http.MethodGet, http.StatusInternalServerError, 1},
+		{"success", policy, http.MethodGet, http.StatusOK, 1},
+		{"non idempotent", policy, http.MethodPost, http.StatusServiceUnavailable, 1},
+		{"non idempotent allowed", RetryPolicy{
+			MaxAttempts:        2,
+			BaseDelay:          time.Millisecond,
+			RetryStatuses:      []int{http.StatusServiceUnavailable},
+			RetryNonIdempotent: true,
+		}, http.MethodPost, http.StatusServiceUnavailable, 2},
+		{"no retry", RetryPolicy{MaxAttempts: 1, RetryStatuses: policy.RetryStatuses}, http.MethodGet, http.StatusServiceUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &statusTransport{status: tt.status}
+			req, _ := http.NewRequest(tt.method, "http://a/", nil)
+
+			resp, err := NewRetry(tt.policy, next).RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want last response %d", resp.StatusCode, tt.status)
+			}
+			if next.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", next.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	var bodies []string
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Header: http.Header{}}, nil
+	})
+
+	rt := NewRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, RetryStatuses: []int{http.StatusServiceUnavailable}}, next)
+	req, _ := http.NewRequest(http.MethodPut, "http://a/", strings.NewReader("payload"))
+	rt.RoundTrip(req)
+
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Errorf("bodies = %q, want payload twice", bodies)
+	}
+}
+
+func TestRetryErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"transport error", errors.New("connection refused"), 3},
+		{"circuit open", ErrCircuitOpen, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return nil, tt.err
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "http://a/", nil)
+			_, err := NewRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}, next).RoundTrip(req)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryAfterIsCappedByMaxDelay(t *testing.T) {
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		h := http.Header{}
+		h.Set("Retry-After", "120")
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody, Header: h}, nil
+	})
+
+	rt := NewRetry(RetryPolicy{MaxAttempts: 2, MaxDelay: 10 * time.Millisecond, RetryStatuses: []int{http.StatusTooManyRequests}}, next)
+	req, _ := http.NewRequest(http.MethodGet, "http://a/", nil)
+
+	now := time.Now()
+	rt.RoundTrip(req)
+	if elapsed := time.Since(now); elapsed > time.Second {
+		t.Errorf("took %s, want Retry-After capped to 10ms", elapsed)
+	}
+}
+
+func TestRetryDoesNotWaitPastDeadline(t *testing.T) {
+	next := &statusTransport{status: http.StatusServiceUnavailable}
+	rt := NewRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, RetryStatuses: []int{http.StatusServiceUnavailable}}, next)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://a/", nil)
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("resp = %v, err = %v, want the last response", resp, err)
+	}
+	if next.calls != 1 {
+		t.Errorf("calls = %d, want 1", next.calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	rt := NewRetry(RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}, nil)
+
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 10: 300 * time.Millisecond} {
+		if got := rt.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+
+	rt.policy.Jitter = true
+	for i := 0; i < 100; i++ {
+		if got := rt.backoff(2); got < 0 || got > 200*time.Millisecond {
+			t.Fatalf("backoff with jitter = %s, want within [0, 200ms]", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
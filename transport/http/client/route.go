@@ -0,0 +1,37 @@
+package client
+
+import "context"
+
+type ctxKey string
+
+const routeCtxKey ctxKey = "client.route"
+
+// UnknownRoute is route recorded for request without WithRoute, since its real path may carry IDs
+// which make a new series for every request.
+const UnknownRoute = "unknown"
+
+// WithRoute returns ctx which carries URL template of outgoing request, example: `/users/{id}`.
+// Recorded metrics and spans use it instead of the real path to keep cardinality low,
+// request without it is recorded as UnknownRoute.
+//
+// Example:
+//
+//	req, _ := http.NewRequestWithContext(client.WithRoute(ctx, "/users/{id}"), http.MethodGet, url, nil)
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeCtxKey, route)
+}
+
+// RouteFromContext returns URL template set by WithRoute.
+func RouteFromContext(ctx context.Context) (string, bool) {
+	route, ok := ctx.Value(routeCtxKey).(string)
+	return route, ok && route != ""
+}
+
+// routeOf returns route set by WithRoute, or UnknownRoute.
+func routeOf(ctx context.Context) string {
+	if route, ok := RouteFromContext(ctx); ok {
+		return route
+	}
+
+	return UnknownRoute
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const otelInstrumentationName = "github.com/dee-el/go-fw/transport/http/client"
+
+// Tracing is http.RoundTripper which creates child span of the current span for every outgoing request,
+// then injects its span context to request headers, so downstream service continues the same trace.
+// Both OpenTracing and OpenTelemetry are supported, set one or both of them.
+type Tracing struct {
+	next http.RoundTripper
+
+	tracer opentracing.Tracer
+
+	otelTracer     trace.Tracer
+	otelPropagator propagation.TextMapPropagator
+}
+
+// NewTracing returns Tracing wrapping next, http.DefaultTransport is used when next is nil.
+//
+// Example:
+//
+//	c := &http.Client{Transport: client.NewTracing(nil, client.OTelTracingOption(otel.GetTracerProvider()))}
+func NewTracing(next http.RoundTripper, opts ...TracingOption) *Tracing {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &Tracing{
+		next:           next,
+		otelPropagator: propagation.TraceContext{},
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+type TracingOption func(*Tracing)
+
+// OpenTracingTracingOption traces using OpenTracing, the same tracer given to middleware.Tracing.
+func OpenTracingTracingOption(tracer opentracing.Tracer) TracingOption {
+	return func(t *Tracing) {
+		t.tracer = tracer
+	}
+}
+
+// OTelTracingOption traces using OpenTelemetry, span context is propagated using W3C `traceparent` header.
+func OTelTracingOption(tp trace.TracerProvider) TracingOption {
+	return func(t *Tracing) {
+		t.otelTracer = tp.Tracer(otelInstrumentationName)
+	}
+}
+
+// PropagatorTracingOption replaces W3C trace context propagator of OpenTelemetry.
+func PropagatorTracingOption(p propagation.TextMapPropagator) TracingOption {
+	return func(t *Tracing) {
+		t.otelPropagator = p
+	}
+}
+
+func (t *Tracing) RoundTrip(req *http.Request) (*http.Response, error) {
+	route := routeOf(req.Context())
+
+	// RoundTripper should not modify the request
+	req = req.Clone(req.Context())
+
+	var span opentracing.Span
+	if t.tracer != nil {
+		operationName := fmt.Sprintf("HTTP %s: %s", req.Method, route)
+
+		var opts []opentracing.StartSpanOption
+		if parent := opentracing.SpanFromContext(req.Context()); parent != nil {
+			opts = append(opts, opentracing.ChildOf(parent.Context()))
+		}
+
+		span = t.tracer.StartSpan(operationName, opts...)
+		defer span.Finish()
+
+		ext.SpanKindRPCClient.Set(span)
+		span.SetTag("component", "net/http")
+		span.SetTag(string(ext.HTTPMethod), req.Method)
+		span.SetTag(string(ext.HTTPUrl), req.URL.String())
+		span.SetTag("http.url_template", route)
+		span.SetTag(string(ext.PeerHostname), req.URL.Hostname())
+
+		// injection failure should not fail the request itself
+		_ = t.tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	}
+
+	var otelSpan trace.Span
+	if t.otelTracer != nil {
+		ctx, s := t.otelTracer.Start(req.Context(), fmt.Sprintf("HTTP %s %s", req.Method, route),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(req.Method),
+				semconv.HTTPURLKey.String(req.URL.String()),
+				semconv.NetPeerNameKey.String(req.URL.Hostname()),
+				attribute.String("http.url_template", route),
+			),
+		)
+		otelSpan = s
+		defer otelSpan.End()
+
+		req = req.WithContext(ctx)
+		t.otelPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		if span != nil {
+			ext.Error.Set(span, true)
+			span.SetTag("error.kind", "internal")
+			span.LogKV("event", "error", "message", err.Error())
+		}
+
+		if otelSpan != nil {
+			otelSpan.RecordError(err)
+			otelSpan.SetStatus(codes.Error, err.Error())
+		}
+
+		return resp, err
+	}
+
+	if span != nil {
+		span.SetTag(string(ext.HTTPStatusCode), resp.StatusCode)
+		// for client span, both 4xx and 5xx are failures
+		if resp.StatusCode >= http.StatusBadRequest {
+			ext.Error.Set(span, true)
+		}
+	}
+
+	if otelSpan != nil {
+		otelSpan.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+		if resp.StatusCode >= http.StatusBadRequest {
+			otelSpan.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+
+	return resp, nil
+}
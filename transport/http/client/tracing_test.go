@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dee-el/go-fw/transport/http/middleware/oteltest"
+)
+
+// headerServer responds status and records headers of every request it receives.
+func headerServer(t *testing.T, status int) (*httptest.Server, *[]http.Header) {
+	t.Helper()
+
+	var headers []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &headers
+}
+
+func TestTracingOTel(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus codes.Code
+	}{
+		{"success", http.StatusOK, codes.Unset},
+		{"client error", http.StatusNotFound, codes.Error},
+		{"server error", http.StatusInternalServerError, codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, headers := headerServer(t, tt.status)
+			tp, exp := oteltest.NewTracerProvider()
+
+			ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+			req, _ := http.NewRequestWithContext(WithRoute(ctx, "/users/{id}"), http.MethodGet, srv.URL+"/users/1", nil)
+
+			resp, err := (&http.Client{Transport: NewTracing(nil, OTelTracingOption(tp))}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			parent.End()
+
+			spans := exp.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want client and parent", len(spans))
+			}
+
+			span := spans[0]
+			if span.Name != "HTTP GET /users/{id}" || span.SpanKind != trace.SpanKindClient {
+				t.Errorf("span = %s (%v), want client span named by route", span.Name, span.SpanKind)
+			}
+			if span.Parent.SpanID() != parent.SpanContext().SpanID() || span.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+				t.Errorf("span parent = %v, want child of the current span", span.Parent)
+			}
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+
+			want := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"
+			if got := (*headers)[0].Get("traceparent"); got != want {
+				t.Errorf("traceparent = %q, want %q", got, want)
+			}
+
+			// RoundTripper should not modify the request
+			if req.Header.Get("traceparent") != "" {
+				t.Errorf("traceparent is set on the caller request")
+			}
+		})
+	}
+}
+
+func TestTracingOpenTracing(t *testing.T) {
+	srv, headers := headerServer(t, http.StatusServiceUnavailable)
+	tracer := mocktracer.New()
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/users/1", nil)
+
+	resp, err := (&http.Client{Transport: NewTracing(nil, OpenTracingTracingOption(tracer))}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans := tracer.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	span := spans[0]
+	if span.OperationName != "HTTP GET: "+UnknownRoute {
+		t.Errorf("operation = %q, want it named by UnknownRoute", span.OperationName)
+	}
+	if span.ParentID != parent.Context().(mocktracer.MockSpanContext).SpanID {
+		t.Errorf("parent = %d, want child of the current span", span.ParentID)
+	}
+	if tags := span.Tags(); tags["http.status_code"] != http.StatusServiceUnavailable || tags["error"] != true {
+		t.Errorf("tags = %v, want failed with the response status", tags)
+	}
+
+	var injected bool
+	for key := range (*headers)[0] {
+		injected = injected || strings.HasPrefix(strings.ToLower(key), "mockpfx-ids-")
+	}
+	if !injected {
+		t.Errorf("headers = %v, want span context injected", (*headers)[0])
+	}
+}
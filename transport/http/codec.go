@@ -0,0 +1,285 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+// Codec encodes and decodes object in one media type.
+type Codec interface {
+	// ContentType is media type handled by codec, example: `application/json`.
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string { return "application/json" }
+
+func (JSONCodec) Encode(w io.Writer, v interface{}) error { return json.NewEncoder(w).Encode(v) }
+
+func (JSONCodec) Decode(r io.Reader, v interface{}) error { return json.NewDecoder(r).Decode(v) }
+
+// XMLCodec encodes using `encoding/xml`, so map in Data is not supported.
+type XMLCodec struct{}
+
+func (XMLCodec) ContentType() string { return "application/xml" }
+
+func (XMLCodec) Encode(w io.Writer, v interface{}) error { return xml.NewEncoder(w).Encode(v) }
+
+func (XMLCodec) Decode(r io.Reader, v interface{}) error { return xml.NewDecoder(r).Decode(v) }
+
+// CBORCodec encodes in CBOR (RFC 8949) binary format, `json` tags are used when `cbor` tags not set.
+type CBORCodec struct{}
+
+func (CBORCodec) ContentType() string { return "application/cbor" }
+
+func (CBORCodec) Encode(w io.Writer, v interface{}) error { return cbor.NewEncoder(w).Encode(v) }
+
+func (CBORCodec) Decode(r io.Reader, v interface{}) error { return cbor.NewDecoder(r).Decode(v) }
+
+// CodecRegistry keeps codecs by its media type.
+// The first registered codec is the default one, used when client does not send `Accept` header.
+type CodecRegistry struct {
+	mu     sync.RWMutex
+	codecs []Codec
+}
+
+func NewCodecRegistry(codecs ...Codec) *CodecRegistry {
+	reg := &CodecRegistry{}
+	for _, c := range codecs {
+		reg.Register(c)
+	}
+
+	return reg
+}
+
+// DefaultCodecs contains JSON (default), XML and CBOR codecs.
+var DefaultCodecs = NewCodecRegistry(JSONCodec{}, XMLCodec{}, CBORCodec{})
+
+// Register adds codec, or replaces codec with the same media type.
+func (reg *CodecRegistry) Register(c Codec) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for i, existing := range reg.codecs {
+		if existing.ContentType() == c.ContentType() {
+			reg.codecs[i] = c
+			return
+		}
+	}
+
+	reg.codecs = append(reg.codecs, c)
+}
+
+// Lookup returns codec of exact media type, parameters such as `charset` are ignored.
+func (reg *CodecRegistry) Lookup(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	for _, c := range reg.codecs {
+		if c.ContentType() == mediaType {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+// Negotiate picks codec based on `Accept` header value, following quality values and wildcards.
+// Empty accept returns the default codec.
+func (reg *CodecRegistry) Negotiate(accept string) (Codec, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	if len(reg.codecs) == 0 {
+		return nil, false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return reg.codecs[0], true
+	}
+
+	ranges := parseAccept(accept)
+
+	var best Codec
+	bestQ, bestSpecificity := 0.0, -1
+	for _, c := range reg.codecs {
+		// quality of media type is taken from the most specific range matching it,
+		// so `application/json;q=0, */*` excludes JSON
+		ar, ok := mostSpecificRange(ranges, c.ContentType())
+		if !ok || ar.q <= 0 {
+			continue
+		}
+
+		if ar.q > bestQ || (ar.q == bestQ && ar.specificity() > bestSpecificity) {
+			best, bestQ, bestSpecificity = c, ar.q, ar.specificity()
+		}
+	}
+
+	return best, best != nil
+}
+
+// Default returns the first registered codec.
+func (reg *CodecRegistry) Default() Codec {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	if len(reg.codecs) == 0 {
+		return JSONCodec{}
+	}
+
+	return reg.codecs[0]
+}
+
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+func (ar acceptRange) match(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (ar.typ == "*" || ar.typ == typ) && (ar.subtype == "*" || ar.subtype == subtype)
+}
+
+// specificity is used to order ranges with the same quality, `text/html` beats `text/*` beats `*/*`.
+func (ar acceptRange) specificity() int {
+	n := 0
+	if ar.typ != "*" {
+		n++
+	}
+	if ar.subtype != "*" {
+		n++
+	}
+
+	return n
+}
+
+// mostSpecificRange returns the most specific range matching mediaType, the first one wins on the same specificity.
+func mostSpecificRange(ranges []acceptRange, mediaType string) (acceptRange, bool) {
+	var found acceptRange
+	ok := false
+	for _, ar := range ranges {
+		if ar.match(mediaType) && (!ok || ar.specificity() > found.specificity()) {
+			found, ok = ar, true
+		}
+	}
+
+	return found, ok
+}
+
+// parseAccept returns media ranges ordered by preference, ranges with q=0 are kept to exclude media types.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+// NegotiatedResponseEncoder returns ResponseEncoder which picks codec from reg based on `Accept` header.
+// It returns errors.ErrorNotAcceptable when nothing matches, so it should be paired with NegotiatedErrorEncoder.
+// Response is encoded before anything is written, so when codec fails to encode it (example: map in XML),
+// the returned error can still be responded by ErrorEncoder.
+//
+// Example:
+//
+//	hn := NewHandler(endpoint, decoder,
+//		WithResponseEncoder(NegotiatedResponseEncoder(DefaultCodecs)),
+//		WithErrorEncoder(NegotiatedErrorEncoder(DefaultCodecs)),
+//	)
+func NegotiatedResponseEncoder(reg *CodecRegistry) ResponseEncoder {
+	return func(ctx context.Context, w http.ResponseWriter, httpStatus int, resp response.Response) error {
+		codec, ok := reg.Negotiate(acceptFromContext(ctx))
+		if !ok {
+			return errors.ErrorNotAcceptable
+		}
+
+		var buf bytes.Buffer
+		err := codec.Encode(&buf, resp)
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", codec.ContentType())
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(httpStatus)
+		_, err = buf.WriteTo(w)
+		return err
+	}
+}
+
+// NegotiatedErrorEncoder returns ErrorEncoder which picks codec from reg based on `Accept` header.
+// When nothing matches, err is still sent as is using default codec, so client does not get 406 instead of it.
+func NegotiatedErrorEncoder(reg *CodecRegistry) ErrorEncoder {
+	return func(ctx context.Context, w http.ResponseWriter, err error) {
+		codec, ok := reg.Negotiate(acceptFromContext(ctx))
+		if !ok {
+			codec = reg.Default()
+		}
+
+		resp, httpStatus := errorResponse(err)
+
+		w.Header().Set("Content-Type", codec.ContentType())
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(httpStatus)
+		// no need check err encoder
+		codec.Encode(w, resp)
+	}
+}
+
+func acceptFromContext(ctx context.Context) string {
+	r, ok := RequestFromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	return r.Header.Get("Accept")
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+func TestCodecRegistryNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/xml", "application/xml"},
+		{"application/*", "application/json"},
+		{"text/html, application/cbor", "application/cbor"},
+		{"application/json;q=0.5, application/xml", "application/xml"},
+		{"application/json;q=0.5, application/xml;q=0.9", "application/xml"},
+		{"*/*;q=0.1, application/cbor;q=0.2", "application/cbor"},
+		{"application/xml;q=0, */*", "application/json"},
+		{"application/json;q=0, */*", "application/xml"},
+		{"application/json;q=0, application/*;q=0.5", "application/xml"},
+		{"*/*;q=0, application/cbor", "application/cbor"},
+		{"*/*;q=0", ""},
+		{"text/html", ""},
+		{"application/json;q=0", ""},
+		{"not a media type", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			codec, ok := DefaultCodecs.Negotiate(tt.accept)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("got %s, want none", codec.ContentType())
+				}
+				return
+			}
+
+			if !ok || codec.ContentType() != tt.want {
+				t.Fatalf("got %v (%v), want %s", codec, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiatedHandler(t *testing.T) {
+	newHandler := func(data interface{}, err error) *Handler {
+		return NewHandler(func(ctx context.Context, request *Request) (response.Response, int, error) {
+			if err != nil {
+				return response.Response{}, 0, err
+			}
+			return *response.NewResponse(data, nil), http.StatusCreated, nil
+		}, nopRequestDecoder,
+			WithResponseEncoder(NegotiatedResponseEncoder(DefaultCodecs)),
+			WithErrorEncoder(NegotiatedErrorEncoder(DefaultCodecs)),
+		)
+	}
+
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	tests := []struct {
+		name            string
+		data            interface{}
+		err             error
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{"json", item{"a"}, nil, "application/json", http.StatusCreated, "application/json", `"name":"a"`},
+		{"xml", item{"a"}, nil, "application/xml", http.StatusCreated, "application/xml", `<name>a</name>`},
+		{"not acceptable", item{"a"}, nil, "text/html", http.StatusNotAcceptable, "application/json", `"code":841`},
+		{"json excluded", item{"a"}, nil, "application/json;q=0, */*", http.StatusCreated, "application/xml", `<name>a</name>`},
+		{"endpoint error is kept when not acceptable", nil, errors.ErrorForbidden, "text/html", http.StatusForbidden, "application/json", `"code":825`},
+		{"internal error is kept when not acceptable", nil, errors.ErrorInternalServer, "text/html", http.StatusInternalServerError, "application/json", `"code":901`},
+		{"xml can not encode map", map[string]string{"a": "b"}, nil, "application/xml", http.StatusInternalServerError, "application/xml", `<code>901</code>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+			s := NewServer()
+			s.Get("/", newHandler(tt.data, tt.err))
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Fatalf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Fatalf("body = %s, want it contains %s", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
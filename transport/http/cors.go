@@ -0,0 +1,159 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	chi_cors "github.com/go-chi/cors"
+
+	"github.com/dee-el/go-fw/config"
+)
+
+// CORSOptions configures Cross-Origin Resource Sharing of Server.
+// For more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
+type CORSOptions struct {
+	// AllowedOrigins is allow-list of origins, `*` allows any origin.
+	// An origin may have one wildcard, example: `https://*.example.com` allows every subdomain of example.com.
+	AllowedOrigins []string
+	// AllowOriginFunc validates origin of the request, when it is set AllowedOrigins is ignored.
+	AllowOriginFunc func(r *http.Request, origin string) bool
+	AllowedMethods  []string
+	AllowedHeaders  []string
+	ExposedHeaders  []string
+	// AllowCredentials lets browser send cookies and authorization header,
+	// it can not be combined with any origin, since browsers reject it anyway.
+	AllowCredentials bool
+	// MaxAge in seconds is how long preflight response can be cached.
+	MaxAge int
+}
+
+// DefaultCORSOptions allows any origin without credentials.
+var DefaultCORSOptions = CORSOptions{
+	AllowedOrigins: []string{"*"},
+	AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+	AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Requested-With"},
+	ExposedHeaders: []string{"Link"},
+	MaxAge:         120, // Maximum value not ignored by any of major browsers
+}
+
+// CORSOptionsFromConfig returns CORSOptions from cfg, empty lists are filled by DefaultCORSOptions.
+func CORSOptionsFromConfig(cfg config.CORSConfig) CORSOptions {
+	opts := CORSOptions{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+
+	if len(opts.AllowedOrigins) == 0 {
+		opts.AllowedOrigins = DefaultCORSOptions.AllowedOrigins
+	}
+
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = DefaultCORSOptions.AllowedMethods
+	}
+
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = DefaultCORSOptions.AllowedHeaders
+	}
+
+	if len(opts.ExposedHeaders) == 0 {
+		opts.ExposedHeaders = DefaultCORSOptions.ExposedHeaders
+	}
+
+	if opts.MaxAge == 0 {
+		opts.MaxAge = DefaultCORSOptions.MaxAge
+	}
+
+	return opts
+}
+
+// validate rejects credentials allowed for any origin.
+func (o CORSOptions) validate() error {
+	if !o.AllowCredentials || o.AllowOriginFunc != nil {
+		return nil
+	}
+
+	if len(o.AllowedOrigins) == 0 {
+		return fmt.Errorf("http: CORS credentials can not be allowed for any origin")
+	}
+
+	for _, origin := range o.AllowedOrigins {
+		if origin == "*" {
+			return fmt.Errorf("http: CORS credentials can not be allowed for any origin, list the origins instead")
+		}
+	}
+
+	return nil
+}
+
+func (o CORSOptions) handler() func(next http.Handler) http.Handler {
+	return chi_cors.New(chi_cors.Options{
+		AllowedOrigins:   o.AllowedOrigins,
+		AllowOriginFunc:  o.AllowOriginFunc,
+		AllowedMethods:   o.AllowedMethods,
+		AllowedHeaders:   o.AllowedHeaders,
+		ExposedHeaders:   o.ExposedHeaders,
+		AllowCredentials: o.AllowCredentials,
+		MaxAge:           o.MaxAge,
+	}).Handler
+}
+
+type routeCORS struct {
+	prefix  string
+	options CORSOptions
+}
+
+// corsMiddleware picks CORS of the longest route prefix matching the request path, or base when none matches.
+// It must be picked by path since preflight request is answered here, before it reaches the mounted routes.
+func corsMiddleware(base CORSOptions, routes []routeCORS) func(next http.Handler) http.Handler {
+	mustValidCORS("", base)
+	for _, rc := range routes {
+		mustValidCORS(rc.prefix, rc.options)
+	}
+
+	// longest first, so the most specific prefix wins
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	return func(next http.Handler) http.Handler {
+		baseHandler := base.handler()(next)
+
+		handlers := make([]http.Handler, len(routes))
+		for i, rc := range routes {
+			handlers[i] = rc.options.handler()(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i, rc := range routes {
+				if hasPathPrefix(r.URL.Path, rc.prefix) {
+					handlers[i].ServeHTTP(w, r)
+					return
+				}
+			}
+
+			baseHandler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// mustValidCORS panics on invalid options, so misconfiguration is found at startup instead of by browsers.
+func mustValidCORS(prefix string, o CORSOptions) {
+	if err := o.validate(); err != nil {
+		if prefix != "" {
+			err = fmt.Errorf("%w, route %s", err, prefix)
+		}
+		panic(err)
+	}
+}
+
+// hasPathPrefix matches prefix on path segments, so `/public` does not match `/publications`.
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dee-el/go-fw/config"
+)
+
+func TestCORSCredentialsForAnyOriginPanics(t *testing.T) {
+	withCredentials := func(origins ...string) CORSOptions {
+		opts := DefaultCORSOptions
+		opts.AllowedOrigins = origins
+		opts.AllowCredentials = true
+		return opts
+	}
+
+	tests := []struct {
+		name      string
+		opts      []ServerOption
+		wantPanic string
+	}{
+		{"wildcard origin", []ServerOption{WithCORS(withCredentials("*"))}, "any origin"},
+		{"wildcard among origins", []ServerOption{WithCORS(withCredentials("https://example.com", "*"))}, "any origin"},
+		{"no origin", []ServerOption{WithCORS(withCredentials())}, "any origin"},
+		{"wildcard origin of route", []ServerOption{WithRouteCORS("/public", withCredentials("*"))}, "route /public"},
+		{"listed origins", []ServerOption{WithCORS(withCredentials("https://example.com", "https://*.example.com"))}, ""},
+		{"wildcard origin without credentials", []ServerOption{WithCORS(DefaultCORSOptions)}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				rvr := recover()
+				if tt.wantPanic == "" {
+					if rvr != nil {
+						t.Fatalf("unexpected panic: %v", rvr)
+					}
+					return
+				}
+
+				err, ok := rvr.(error)
+				if !ok || !strings.Contains(err.Error(), tt.wantPanic) {
+					t.Fatalf("panic = %v, want it contains %q", rvr, tt.wantPanic)
+				}
+			}()
+
+			NewServer(tt.opts...)
+		})
+	}
+}
+
+func TestCORSOptionsFromConfig(t *testing.T) {
+	opts := CORSOptionsFromConfig(config.CORSConfig{
+		AllowedOrigins:   []string{"https://example.com"},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+	})
+
+	if strings.Join(opts.AllowedOrigins, ",") != "https://example.com" || strings.Join(opts.AllowedMethods, ",") != http.MethodGet ||
+		!opts.AllowCredentials {
+		t.Errorf("options = %+v, want the configured ones", opts)
+	}
+
+	// empty ones are filled by DefaultCORSOptions
+	if strings.Join(opts.AllowedHeaders, ",") != strings.Join(DefaultCORSOptions.AllowedHeaders, ",") ||
+		strings.Join(opts.ExposedHeaders, ",") != strings.Join(DefaultCORSOptions.ExposedHeaders, ",") ||
+		opts.MaxAge != DefaultCORSOptions.MaxAge {
+		t.Errorf("options = %+v, want defaults for the rest", opts)
+	}
+
+	if opts := CORSOptionsFromConfig(config.CORSConfig{}); strings.Join(opts.AllowedOrigins, ",") != "*" {
+		t.Errorf("origins = %v, want default", opts.AllowedOrigins)
+	}
+}
+
+func TestCORSPreflightThroughServer(t *testing.T) {
+	s := NewServer(WithConfig(config.ServerConfig{
+		CORS: config.CORSConfig{
+			AllowedOrigins:   []string{"https://example.com", "https://*.example.com"},
+			AllowCredentials: true,
+			Routes: map[string]config.CORSConfig{
+				"/public":         {AllowedOrigins: []string{"*"}},
+				"/public/private": {AllowedOrigins: []string{"https://admin.example.com"}},
+			},
+		},
+	}))
+	s.Get("/items", okHandler())
+
+	tests := []struct {
+		name            string
+		path            string
+		origin          string
+		wantOrigin      string
+		wantCredentials bool
+	}{
+		{"listed origin", "/items", "https://example.com", "https://example.com", true},
+		{"wildcard subdomain", "/items", "https://api.example.com", "https://api.example.com", true},
+		{"nested wildcard subdomain", "/items", "https://v1.api.example.com", "https://v1.api.example.com", true},
+		{"other origin", "/items", "https://evil.com", "", false},
+		{"origin ending like the domain", "/items", "https://example.com.evil.com", "", false},
+		{"origin of other scheme", "/items", "http://api.example.com", "", false},
+		{"any origin of route", "/public/items", "https://evil.com", "*", false},
+		{"route prefix is matched by segment", "/publications", "https://evil.com", "", false},
+		{"longest route prefix wins", "/public/private/items", "https://evil.com", "", false},
+		{"origin of longest route prefix", "/public/private/items", "https://admin.example.com", "https://admin.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			r.Header.Set("Origin", tt.origin)
+			r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			w := httptest.NewRecorder()
+
+			s.Handler().ServeHTTP(w, r)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %v, want %v", got, tt.wantCredentials)
+			}
+		})
+	}
+
+	// actual request after the preflight
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	r.Header.Set("Origin", "https://api.example.com")
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://api.example.com" {
+		t.Errorf("status = %d, headers = %v, want allowed request", w.Code, w.Header())
+	}
+}
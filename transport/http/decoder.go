@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+)
+
+// BodyDecoder decodes request body of a media type into payload.
+// Returned *errors.Error will be sent to client as is.
+type BodyDecoder func(r *http.Request, payload interface{}) error
+
+// WithBodyDecoder registers dec for mediaType, example: `application/x-protobuf`.
+// It takes precedence over built-in decoders and codecs.
+func WithBodyDecoder(mediaType string, dec BodyDecoder) ParserOption {
+	return func(p *parser) {
+		p.decoders[mediaType] = dec
+	}
+}
+
+// WithBodyCodecs replaces codecs used to decode body, default is DefaultCodecs.
+// So registering codec to DefaultCodecs will make it available for both request and response.
+func WithBodyCodecs(reg *CodecRegistry) ParserOption {
+	return func(p *parser) {
+		p.codecs = reg
+	}
+}
+
+// WithMaxBodyBytes limits request body size, errors.ErrorPayloadTooLarge returned when it is exceeded.
+// Default is no limit.
+func WithMaxBodyBytes(n int64) ParserOption {
+	return func(p *parser) {
+		p.maxBodyBytes = n
+	}
+}
+
+// WithStrictJSON rejects JSON body which has fields unknown to payload.
+func WithStrictJSON() ParserOption {
+	return func(p *parser) {
+		p.strictJSON = true
+	}
+}
+
+// WithParserOptions is an option to adjust RequestParser called within Handler.
+//
+// Example:
+//
+//	hn := NewTypedHandler(createUser, WithParserOptions(WithStrictJSON(), WithMaxBodyBytes(1<<20)))
+func WithParserOptions(opts ...ParserOption) HandlerOption {
+	return func(h *Handler) {
+		h.parser = newParser(opts...)
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"github.com/dee-el/go-fw/errors"
 )
 
+// StatusClientClosedRequest is non-standard status (popularized by nginx) for request canceled by client,
+// so it can be told apart from server failure in metrics and logs.
+const StatusClientClosedRequest = 499
+
 // Dictionary is used to store a mapping from `error.Type` to http status.
 // This is to make it easy for user when err happens to its http status as business rule.
 type Dictionary map[errors.Type]int
@@ -18,6 +22,11 @@ var DefaultDictionary Dictionary = Dictionary{
 	errors.TypeInternalServerError:   http.StatusInternalServerError,
 	errors.TypeMaintenanceError:      http.StatusServiceUnavailable,
 	errors.TypeBadRequestError:       http.StatusBadRequest,
+	errors.TypeNotAcceptableError:    http.StatusNotAcceptable,
+	errors.TypeUnsupportedMediaError: http.StatusUnsupportedMediaType,
+	errors.TypePayloadTooLargeError:  http.StatusRequestEntityTooLarge,
+	errors.TypeClientClosedError:     StatusClientClosedRequest,
+	errors.TypeTimeoutError:          http.StatusGatewayTimeout,
 }
 
 var dictionary = DefaultDictionary
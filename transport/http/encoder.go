@@ -10,15 +10,33 @@ import (
 )
 
 // JSONResponseEncoder encodes the passed response object to the HTTP response writer in JSON format.
+// It is encoded before anything is written, so encoding error can still be responded by ErrorEncoder.
 func JSONResponseEncoder(ctx context.Context, w http.ResponseWriter, httpStatus int, res response.Response) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatus)
-	return json.NewEncoder(w).Encode(res)
+	// keep trailing newline as json.Encoder does
+	_, err = w.Write(append(b, '\n'))
+	return err
 }
 
 // JSONResponseEncoder encodes the passed err to client in JSON format.
 // Using Dictionary to help directing err to each own HTTP status.
 func JSONErrorEncoder(ctx context.Context, w http.ResponseWriter, err error) {
+	resp, httpStatus := errorResponse(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	// no need check err encoder
+	json.NewEncoder(w).Encode(resp)
+}
+
+// errorResponse returns standard response of err and its HTTP status from Dictionary.
+func errorResponse(err error) (*response.Response, int) {
 	// empty response, value will fill from type checking err
 	// same response to standardize format response
 	resp := response.NewResponse(nil, nil)
@@ -38,8 +56,5 @@ func JSONErrorEncoder(ctx context.Context, w http.ResponseWriter, err error) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(httpStatus)
-	// no need check err encoder
-	json.NewEncoder(w).Encode(resp)
+	return resp, httpStatus
 }
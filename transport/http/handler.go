@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
@@ -39,6 +40,13 @@ type Handler struct {
 	responseEncoder ResponseEncoder
 	errorEncoder    ErrorEncoder
 	errorHandler    ErrorHandler
+	parser          *parser
+	logger          *zap.Logger
+	timeout         time.Duration
+	policy          *Policy
+
+	// options given on NewHandler, they take precedence over options inherited from Server
+	opts []HandlerOption
 }
 
 type HandlerOption func(h *Handler)
@@ -64,8 +72,17 @@ func WithErrorHandler(errorHandler ErrorHandler) HandlerOption {
 	}
 }
 
-var logger, _ = zap.NewProduction(zap.AddStacktrace(zap.PanicLevel), zap.WithCaller(false))
-var logged = LoggedErrorHandler(logger)
+// WithTimeout is an option to limit how long Handler may take, the endpoint sees it as ctx deadline.
+// When the deadline is exceeded, errors.ErrorTimeout is responded, and when client cancels the request,
+// errors.ErrorClientClosed is responded instead.
+// Handler registered to Server inherits server timeout, this option overrides it, zero means no timeout.
+func WithTimeout(timeout time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.timeout = timeout
+	}
+}
+
+var logged = LoggedErrorHandler(defaultLogger)
 
 func NewHandler(endpoint Endpoint, requestDecoder RequestDecoder, opts ...HandlerOption) *Handler {
 	h := &Handler{
@@ -74,17 +91,53 @@ func NewHandler(endpoint Endpoint, requestDecoder RequestDecoder, opts ...Handle
 		responseEncoder: JSONResponseEncoder,
 		errorEncoder:    JSONErrorEncoder,
 		errorHandler:    logged,
+		logger:          defaultLogger,
 	}
 
 	for _, opt := range opts {
 		opt(h)
 	}
+	h.opts = opts
 
 	return h
 }
 
+// inherit returns copy of h with options from Server, then re-applies the handler own options so they win.
+// h itself is left as is, so the same Handler can be registered to multiple servers.
+func (h *Handler) inherit(opts ...HandlerOption) *Handler {
+	if len(opts) == 0 {
+		return h
+	}
+
+	cp := *h
+	for _, opt := range opts {
+		opt(&cp)
+	}
+
+	for _, opt := range cp.opts {
+		opt(&cp)
+	}
+
+	return &cp
+}
+
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	// keep the request, so encoders can look into it, example: `Accept` header
+	ctx = context.WithValue(ctx, requestCtxKey, r)
+	if h.parser != nil {
+		// picked up by RequestParser
+		ctx = context.WithValue(ctx, parserCtxKey, h.parser)
+	}
+	// picked up by LoggerFromContext
+	ctx = ContextWithLogger(ctx, requestLogger(h.logger, r))
+	r = r.WithContext(ctx)
 
 	// leverage chi context
 	chiCtx := chi.RouteContext(ctx)
@@ -99,6 +152,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			h.errorHandler(r, err)
 		}
 
+		recordSpanError(ctx, err)
 		h.errorEncoder(ctx, w, err)
 		return
 	}
@@ -108,30 +162,65 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		request.URLParams = urlParams
 	}
 
-	select {
-	case <-ctx.Done():
-		if ctx.Err() == context.DeadlineExceeded {
-			h.errorHandler(r, ctx.Err())
-			h.errorEncoder(ctx, w, errors.ErrorInternalServer)
-			return
-		}
-	default:
-		// process
-		response, httpStatus, err := h.endpoint(ctx, request)
-		if err != nil {
-			h.errorHandler(r, err)
-			h.errorEncoder(ctx, w, err)
-			return
-		}
+	// same as url params, overwrite when request decoder did not retrieve querystring
+	if len(request.Query) == 0 {
+		request.Query = r.URL.Query()
+	}
 
-		// output
-		err = h.responseEncoder(ctx, w, httpStatus, response)
-		if err != nil {
-			h.errorHandler(r, err)
-			h.errorEncoder(ctx, w, err)
+	// request may be already expired or canceled while being decoded
+	if ctx.Err() != nil {
+		h.handleContextError(ctx, w, r, ctx.Err())
+		return
+	}
+
+	// process
+	response, httpStatus, err := h.endpoint(ctx, request)
+	if err != nil {
+		// endpoint usually fails because of its ctx when it is done
+		if ctx.Err() != nil {
+			h.handleContextError(ctx, w, r, err)
 			return
 		}
+
+		h.errorHandler(r, err)
+		recordSpanError(ctx, err)
+		h.errorEncoder(ctx, w, err)
+		return
+	}
+
+	// output
+	err = h.responseEncoder(ctx, w, httpStatus, response)
+	if err != nil {
+		h.errorHandler(r, err)
+		recordSpanError(ctx, err)
+		h.errorEncoder(ctx, w, err)
+		return
+	}
+}
+
+// handleContextError responds request whose ctx is done, err is the error caused by it.
+// Deadline exceeded is server failure to be handled, while client cancellation is not.
+func (h *Handler) handleContextError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	if ctx.Err() == context.DeadlineExceeded {
+		h.errorHandler(r, err)
+		recordSpanError(ctx, errors.ErrorTimeout)
+		h.errorEncoder(ctx, w, errors.ErrorTimeout)
+		return
 	}
+
+	recordSpanError(ctx, errors.ErrorClientClosed)
+	// nobody may read it, but it lets middleware, example: metrics, see the request as canceled
+	h.errorEncoder(ctx, w, errors.ErrorClientClosed)
+}
+
+type ctxKey string
+
+const requestCtxKey ctxKey = "http.request"
+
+// RequestFromContext returns incoming request from ctx passed by Handler to its encoders and endpoint.
+func RequestFromContext(ctx context.Context) (*http.Request, bool) {
+	r, ok := ctx.Value(requestCtxKey).(*http.Request)
+	return r, ok
 }
 
 func getURLParams(chiCtx *chi.Context) URLParams {
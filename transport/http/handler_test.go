@@ -0,0 +1,140 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+func nopRequestDecoder(ctx context.Context, r *http.Request) (*Request, error) {
+	return &Request{}, nil
+}
+
+func TestHandlerRegisteredToMultipleServers(t *testing.T) {
+	hn := NewHandler(func(ctx context.Context, request *Request) (response.Response, int, error) {
+		return response.Response{}, 0, errors.ErrorNotFound
+	}, nopRequestDecoder)
+
+	plain := NewServer()
+	problem := NewServer(WithDefaultErrorEncoder(ProblemJSONErrorEncoder))
+	plain.Get("/x", hn)
+	problem.Get("/x", hn)
+
+	tests := []struct {
+		name        string
+		server      *Server
+		contentType string
+	}{
+		{"plain", plain, "application/json"},
+		{"problem", problem, "application/problem+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Fatalf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+		})
+	}
+}
+
+// waitForDeadline responds 200 when ctx has no deadline, otherwise it waits until ctx is done.
+func waitForDeadline(ctx context.Context, request *Request) (response.Response, int, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return *response.NewResponse("no deadline", nil), http.StatusOK, nil
+	}
+
+	<-ctx.Done()
+	return response.Response{}, 0, ctx.Err()
+}
+
+func TestHandlerContextErrors(t *testing.T) {
+	serverTimeout := WithHandlerOptions(WithTimeout(10 * time.Millisecond))
+
+	tests := []struct {
+		name            string
+		server          []ServerOption
+		handler         []HandlerOption
+		cancel          bool
+		wantStatus      int
+		wantCode        string
+		wantErrorHandle int
+	}{
+		{"server timeout", []ServerOption{serverTimeout}, nil, false, http.StatusGatewayTimeout, `"code":911`, 1},
+		{"handler without timeout overrides server", []ServerOption{serverTimeout}, []HandlerOption{WithTimeout(0)}, false, http.StatusOK, "", 0},
+		{"handler timeout overrides server without timeout", []ServerOption{WithTimeoutInSecond(0)}, []HandlerOption{WithTimeout(10 * time.Millisecond)}, false, http.StatusGatewayTimeout, `"code":911`, 1},
+		{"server without timeout", []ServerOption{WithTimeoutInSecond(0)}, nil, false, http.StatusOK, "", 0},
+		{"canceled by client", nil, nil, true, StatusClientClosedRequest, `"code":844`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled int
+			opts := append([]HandlerOption{WithErrorHandler(func(r *http.Request, err error) { handled++ })}, tt.handler...)
+
+			s := NewServer(tt.server...)
+			s.Get("/", NewHandler(waitForDeadline, nopRequestDecoder, opts...))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cancel {
+				ctx, cancel := context.WithCancel(r.Context())
+				cancel()
+				r = r.WithContext(ctx)
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Errorf("body = %s, want it contains %s", w.Body, tt.wantCode)
+			}
+			// client cancellation is not server failure
+			if handled != tt.wantErrorHandle {
+				t.Errorf("error handler called %d times, want %d", handled, tt.wantErrorHandle)
+			}
+		})
+	}
+}
+
+func TestMethodFuncTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{"server timeout", 1, true},
+		{"server without timeout", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			var ok bool
+			s := NewServer(WithTimeoutInSecond(tt.timeout))
+			s.MethodFunc(http.MethodGet, "/raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, ok = r.Context().Deadline()
+			}))
+
+			s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/raw", nil))
+			served := time.Now()
+
+			if ok != tt.wantDeadline {
+				t.Fatalf("has deadline = %v, want %v", ok, tt.wantDeadline)
+			}
+			if ok && deadline.After(served.Add(time.Second)) {
+				t.Errorf("deadline in %s, want within server timeout", deadline.Sub(served))
+			}
+		})
+	}
+}
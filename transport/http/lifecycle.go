@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ShutdownError is returned by Run when server stopped after being asked to shutdown,
+// either by canceled context or by receiving SIGINT / SIGTERM.
+// Forced will be true when in-flight requests can not be drained within graceful timeout,
+// in that case the remaining connections are closed immediately.
+type ShutdownError struct {
+	// Signal is the signal received, nil when shutdown triggered by context.
+	Signal os.Signal
+	Forced bool
+	// Err is the cause why server can not be stopped cleanly, nil when Forced is false.
+	Err error
+}
+
+func (e *ShutdownError) Error() string {
+	trigger := "context done"
+	if e.Signal != nil {
+		trigger = e.Signal.String()
+	}
+
+	if e.Forced {
+		return fmt.Sprintf("http server shutdown forced (%s): %v", trigger, e.Err)
+	}
+
+	return fmt.Sprintf("http server shutdown gracefully (%s)", trigger)
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// IsCleanShutdown reports whether err returned from Run means server was stopped gracefully.
+func IsCleanShutdown(err error) bool {
+	var se *ShutdownError
+	if errors.As(err, &se) {
+		return !se.Forced
+	}
+
+	return false
+}
+
+// ListenAndServe is shorthand of Run with background context.
+func (s *Server) ListenAndServe() error {
+	return s.Run(context.Background())
+}
+
+// Run starts HTTP server on configured address and blocks until it stops.
+// Server stops when ctx is done or SIGINT / SIGTERM received, then it waits in-flight requests
+// to be finished within graceful timeout.
+//
+// Returned error will be *ShutdownError when server was asked to stop,
+// otherwise it is the error why server can not serve at all (example: port already in use).
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	return s.serve(ctx, ln)
+}
+
+// serve is Run on listener ln, which is closed when it returns.
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Addr:         s.addr,
+		Handler:      s.mux,
+		ReadTimeout:  s.readTimeout,
+		WriteTimeout: s.writeTimeout,
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	shutdownErr := &ShutdownError{}
+	select {
+	case err := <-errCh:
+		// server never got asked to stop, so this is a failure to serve
+		return err
+	case sig := <-sigCh:
+		shutdownErr.Signal = sig
+	case <-ctx.Done():
+	}
+
+	// parent ctx might be already done, graceful timeout should not depend on it
+	gracefulCtx, cancel := context.WithTimeout(context.Background(), s.gracefulTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(gracefulCtx)
+	if err != nil {
+		shutdownErr.Forced = true
+		shutdownErr.Err = err
+
+		// drop all remaining connections
+		srv.Close()
+	}
+
+	return shutdownErr
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ln
+}
+
+func TestRunShutdownGracefully(t *testing.T) {
+	s := NewServer()
+	s.Get("/", okHandler())
+	ln := listen(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.serve(ctx, ln)
+	}()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	cancel()
+	err = <-errCh
+
+	var se *ShutdownError
+	if !stderrors.As(err, &se) || se.Forced || se.Signal != nil || se.Err != nil {
+		t.Fatalf("err = %#v, want clean ShutdownError triggered by context", err)
+	}
+	if !IsCleanShutdown(err) {
+		t.Errorf("IsCleanShutdown(%v) = false, want true", err)
+	}
+}
+
+func TestRunShutdownForced(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	s := NewServer()
+	s.gracefulTimeout = 50 * time.Millisecond
+	s.MethodFunc(http.MethodGet, "/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	ln := listen(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.serve(ctx, ln)
+	}()
+
+	clientErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		clientErr <- err
+	}()
+
+	<-started
+	cancel()
+
+	err := <-errCh
+	var se *ShutdownError
+	if !stderrors.As(err, &se) || !se.Forced {
+		t.Fatalf("err = %#v, want forced ShutdownError", err)
+	}
+	if !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want it wraps context.DeadlineExceeded", err)
+	}
+	if IsCleanShutdown(err) {
+		t.Errorf("IsCleanShutdown(%v) = true, want false", err)
+	}
+
+	// remaining connection is closed
+	if err := <-clientErr; err == nil {
+		t.Errorf("in-flight request succeeded, want its connection closed")
+	}
+}
+
+func TestRunFailsToServe(t *testing.T) {
+	ln := listen(t)
+	defer ln.Close()
+
+	s := NewServer()
+	s.addr = ln.Addr().String()
+
+	err := s.Run(context.Background())
+	var se *ShutdownError
+	if err == nil || stderrors.As(err, &se) {
+		t.Fatalf("err = %v, want failure to listen", err)
+	}
+	if IsCleanShutdown(err) {
+		t.Errorf("IsCleanShutdown(%v) = true, want false", err)
+	}
+}
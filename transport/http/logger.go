@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	chi "github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+
+	"github.com/dee-el/go-fw/transport/http/middleware"
+)
+
+// defaultLogger is used by Handler when neither WithLogger nor WithHandlerLogger is given.
+var defaultLogger = newDefaultLogger()
+
+func newDefaultLogger() *zap.Logger {
+	logger, err := zap.NewProduction(zap.AddStacktrace(zap.PanicLevel), zap.WithCaller(false))
+	if err != nil {
+		// nothing to report to, keep serving without log
+		return zap.NewNop()
+	}
+
+	return logger
+}
+
+const loggerCtxKey ctxKey = "http.logger"
+
+// WithHandlerLogger is an option to replace logger on Handler, it is used by its ErrorHandler
+// and passed to endpoint through ctx, see LoggerFromContext.
+func WithHandlerLogger(logger *zap.Logger) HandlerOption {
+	return func(h *Handler) {
+		h.logger = logger
+		h.errorHandler = LoggedErrorHandler(logger)
+	}
+}
+
+// LoggerFromContext returns logger carried by ctx, with `request_id`, `request_path` and `trace_id` fields
+// of current request already attached. It returns the default logger when ctx does not carry any.
+//
+// Example:
+//
+//	func (ctx context.Context, req *http.Request) (response.Response, int, error) {
+//		http.LoggerFromContext(ctx).Info("creating order", zap.String("user_id", userID))
+//		...
+//	}
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerCtxKey).(*zap.Logger); ok {
+		return logger
+	}
+
+	return defaultLogger
+}
+
+// ContextWithLogger returns copy of ctx carrying logger, so it can be retrieved by LoggerFromContext.
+func ContextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, logger)
+}
+
+// requestLogger returns logger with fields of r attached.
+func requestLogger(logger *zap.Logger, r *http.Request) *zap.Logger {
+	ctx := r.Context()
+	path := r.URL.Path
+	// route is already resolved when Handler is reached
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		path = rctx.RoutePattern()
+	}
+
+	fields := []zap.Field{
+		zap.String("request_path", path),
+		zap.String("request_method", r.Method),
+	}
+
+	if reqID := chi_middleware.GetReqID(ctx); reqID != "" {
+		fields = append(fields, zap.String("request_id", reqID))
+	}
+
+	if traceID := middleware.TraceIDFromContext(ctx); traceID != "" {
+		fields = append(fields, zap.String("trace_id", traceID))
+	}
+
+	return logger.With(fields...)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/dee-el/go-fw/transport/http/middleware"
+	"github.com/dee-el/go-fw/transport/http/middleware/oteltest"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+func TestLoggerFromContext(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	tp, exp := oteltest.NewTracerProvider()
+
+	s := NewServer(WithLogger(zap.New(core)), WithOTelTracing(middleware.NewOTelTracing(tp)))
+	s.Route("/v1", func(sub *Server) {
+		sub.Get("/items/{id}", NewHandler(func(ctx context.Context, request *Request) (response.Response, int, error) {
+			LoggerFromContext(ctx).Info("getting item")
+			return response.Response{}, 0, stderrors.New("db down")
+		}, nopRequestDecoder))
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/items/1", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	s.Handler().ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	entries := logs.FilterMessage("getting item").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d logs of the endpoint, want 1 on the server logger", len(entries))
+	}
+
+	want := map[string]interface{}{
+		"request_path":   "/v1/items/{id}",
+		"request_method": http.MethodGet,
+		"request_id":     "req-1",
+		"trace_id":       spans[0].SpanContext.TraceID().String(),
+	}
+	fields := entries[0].ContextMap()
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, fields[key], value)
+		}
+	}
+
+	// error of the endpoint is logged by the same logger
+	if n := logs.FilterMessage("HTTP error").Len(); n != 1 {
+		t.Errorf("got %d error logs, want 1", n)
+	}
+}
+
+func TestHandlerLoggerOverridesServerLogger(t *testing.T) {
+	serverCore, serverLogs := observer.New(zapcore.InfoLevel)
+	handlerCore, handlerLogs := observer.New(zapcore.InfoLevel)
+
+	s := NewServer(WithLogger(zap.New(serverCore)))
+	s.Route("/v1", func(sub *Server) {
+		sub.Get("/", NewHandler(func(ctx context.Context, request *Request) (response.Response, int, error) {
+			LoggerFromContext(ctx).Info("hit")
+			return response.Response{}, http.StatusOK, nil
+		}, nopRequestDecoder, WithHandlerLogger(zap.New(handlerCore))))
+	})
+
+	s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/", nil))
+
+	if serverLogs.Len() != 0 || handlerLogs.Len() != 1 {
+		t.Errorf("server logs = %d, handler logs = %d, want only the handler one", serverLogs.Len(), handlerLogs.Len())
+	}
+}
+
+func TestLoggerFromContextWithoutLogger(t *testing.T) {
+	if LoggerFromContext(context.Background()) != defaultLogger {
+		t.Errorf("want default logger for ctx without logger")
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/transport/http/middleware"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+// MaintenanceUpdate is request of NewMaintenanceUpdateHandler, omitted field is kept as is.
+type MaintenanceUpdate struct {
+	// Enabled turns maintenance mode on or off right away.
+	Enabled *bool `json:"enabled" xml:"enabled" form:"enabled"`
+	// Window schedules maintenance, empty window (`{}`) clears the schedule.
+	Window *MaintenanceWindow `json:"window" xml:"window" form:"window"`
+}
+
+// MaintenanceWindow is scheduled maintenance, zero End means until it is cleared.
+type MaintenanceWindow struct {
+	Start time.Time `json:"start" xml:"start" form:"start"`
+	End   time.Time `json:"end" xml:"end" form:"end"`
+}
+
+// NewMaintenanceStatusHandler returns Handler responding middleware.MaintenanceStatus of m.
+func NewMaintenanceStatusHandler(m *middleware.Maintenance, opts ...HandlerOption) *Handler {
+	endpoint := func(ctx context.Context, request *Request) (response.Response, int, error) {
+		return *response.NewResponse(m.Status(), nil), http.StatusOK, nil
+	}
+
+	decoder := func(ctx context.Context, r *http.Request) (*Request, error) {
+		return &Request{}, nil
+	}
+
+	return NewHandler(endpoint, decoder, opts...)
+}
+
+// NewMaintenanceUpdateHandler returns Handler toggling or scheduling maintenance of m, it responds the new status.
+// Anyone reaching it can take the service down, so protect it with policy and allow it during maintenance,
+// otherwise maintenance can not be turned off from it.
+//
+// Example:
+//
+//	s := NewServer(WithMaintenance(middleware.AllowedPathsMaintenanceOption("/admin/*")))
+//	s.Get("/admin/maintenance", NewMaintenanceStatusHandler(s.Maintenance(), WithPolicy(RequireRoles("admin"))))
+//	s.Put("/admin/maintenance", NewMaintenanceUpdateHandler(s.Maintenance(), WithPolicy(RequireRoles("admin"))))
+func NewMaintenanceUpdateHandler(m *middleware.Maintenance, opts ...HandlerOption) *Handler {
+	return NewTypedHandler(func(ctx context.Context, req *TypedRequest[MaintenanceUpdate]) (middleware.MaintenanceStatus, int, error) {
+		update := req.Payload
+		if w := update.Window; w != nil {
+			if err := m.Schedule(w.Start, w.End); err != nil {
+				e := errors.ErrorBadRequest.Copy()
+				e.AddField("window", "end must be after start")
+				return middleware.MaintenanceStatus{}, 0, e
+			}
+		}
+
+		if update.Enabled != nil {
+			if *update.Enabled {
+				m.Enable()
+			} else {
+				m.Disable()
+			}
+		}
+
+		return m.Status(), http.StatusOK, nil
+	}, opts...)
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLog logs one structured entry for every request.
+type AccessLog struct {
+	logger *zap.Logger
+	// sampleRate is ratio of successful requests (status < 400) to be logged, failed requests are always logged.
+	// By default will be 1, log everything.
+	sampleRate float64
+	// excludedPaths are never logged, can be route pattern or URL path, example: `/health`.
+	excludedPaths map[string]struct{}
+	// level decides log level by HTTP status.
+	level func(status int) zapcore.Level
+}
+
+func NewAccessLog(logger *zap.Logger, opts ...AccessLogOption) *AccessLog {
+	a := &AccessLog{
+		logger:        logger,
+		sampleRate:    1,
+		excludedPaths: map[string]struct{}{},
+		level:         levelByStatus,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+type AccessLogOption func(*AccessLog)
+
+func SampleRateAccessLogOption(rate float64) AccessLogOption {
+	return func(a *AccessLog) {
+		a.sampleRate = rate
+	}
+}
+
+func ExcludedPathsAccessLogOption(paths ...string) AccessLogOption {
+	return func(a *AccessLog) {
+		for _, p := range paths {
+			a.excludedPaths[p] = struct{}{}
+		}
+	}
+}
+
+func LevelAccessLogOption(level func(status int) zapcore.Level) AccessLogOption {
+	return func(a *AccessLog) {
+		a.level = level
+	}
+}
+
+// levelByStatus logs 5xx as error, 4xx as warn, and the rest as info.
+func levelByStatus(status int) zapcore.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	}
+
+	return zapcore.InfoLevel
+}
+
+func (a *AccessLog) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := a.excludedPaths[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rw := chi_middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		now := time.Now()
+		next.ServeHTTP(rw, r)
+		latency := time.Since(now)
+
+		// since using chi as template mux http, utilize chi context to get regexed pattern path
+		path := r.URL.Path
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			path = rctx.RoutePattern()
+			if _, ok := a.excludedPaths[path]; ok {
+				return
+			}
+		}
+
+		status := rw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		if status < http.StatusBadRequest && a.sampleRate < 1 && rand.Float64() >= a.sampleRate {
+			return
+		}
+
+		fields := []zap.Field{
+			zap.String("request_path", path),
+			zap.String("request_method", r.Method),
+			zap.Int("status", status),
+			zap.Int("bytes_written", rw.BytesWritten()),
+			zap.Duration("latency", latency),
+			zap.String("request_id", chi_middleware.GetReqID(r.Context())),
+			zap.String("request_ip", getIP(r)),
+			zap.String("user_agent", r.UserAgent()),
+		}
+
+		// only available when this middleware is registered after tracing middleware
+		if traceID := TraceIDFromContext(r.Context()); traceID != "" {
+			fields = append(fields, zap.String("trace_id", traceID))
+		}
+
+		if ce := a.logger.Check(a.level(status), "HTTP access"); ce != nil {
+			ce.Write(fields...)
+		}
+	})
+}
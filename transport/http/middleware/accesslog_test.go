@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	chi "github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []AccessLogOption
+		path      string
+		wantLevel zapcore.Level
+		wantPath  string
+		wantLog   bool
+	}{
+		{"success", nil, "/status/200", zapcore.InfoLevel, "/status/{code}", true},
+		{"client error", nil, "/status/404", zapcore.WarnLevel, "/status/{code}", true},
+		{"server error", nil, "/status/503", zapcore.ErrorLevel, "/status/{code}", true},
+		{"unmatched route", nil, "/.env", zapcore.WarnLevel, "/.env", true},
+		{"excluded URL path", []AccessLogOption{ExcludedPathsAccessLogOption("/status/200")}, "/status/200", 0, "", false},
+		{"excluded route pattern", []AccessLogOption{ExcludedPathsAccessLogOption("/status/{code}")}, "/status/500", 0, "", false},
+		{"success not sampled", []AccessLogOption{SampleRateAccessLogOption(0)}, "/status/200", 0, "", false},
+		{"failure is always logged", []AccessLogOption{SampleRateAccessLogOption(0)}, "/status/400", zapcore.WarnLevel, "/status/{code}", true},
+		{"custom level", []AccessLogOption{LevelAccessLogOption(func(status int) zapcore.Level {
+			return zapcore.DebugLevel
+		})}, "/status/500", zapcore.DebugLevel, "/status/{code}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+
+			r := chi.NewRouter()
+			r.Use(NewAccessLog(zap.New(core), tt.opts...).Handler)
+			r.Get("/status/{code}", func(w http.ResponseWriter, r *http.Request) {
+				code, _ := strconv.Atoi(chi.URLParam(r, "code"))
+				w.WriteHeader(code)
+			})
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			entries := logs.All()
+			if !tt.wantLog {
+				if len(entries) != 0 {
+					t.Fatalf("got %d logs, want none", len(entries))
+				}
+				return
+			}
+
+			if len(entries) != 1 {
+				t.Fatalf("got %d logs, want 1", len(entries))
+			}
+			if entries[0].Level != tt.wantLevel {
+				t.Errorf("level = %s, want %s", entries[0].Level, tt.wantLevel)
+			}
+			if got := entries[0].ContextMap()["request_path"]; got != tt.wantPath {
+				t.Errorf("request_path = %v, want %s", got, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestAccessLogWithoutRouteContext(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	h := NewAccessLog(zap.New(core)).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/anything", nil))
+
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].ContextMap()["request_path"] != "/anything" {
+		t.Errorf("logs = %v, want one with URL path", entries)
+	}
+}
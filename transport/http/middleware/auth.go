@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+var (
+	// ErrNoCredentials is returned by Authenticator when request does not carry credentials it knows,
+	// so the next Authenticator on ChainAuthenticators can try.
+	ErrNoCredentials = stderrors.New("middleware: no credentials")
+	// ErrInvalidCredentials is returned by Authenticator when credentials are wrong.
+	ErrInvalidCredentials = stderrors.New("middleware: invalid credentials")
+)
+
+// Principal is who sends the request, as told by Authenticator.
+type Principal struct {
+	ID string
+	// Scheme is how principal is authenticated, example: `Bearer`, `APIKey` or `Basic`.
+	Scheme      string
+	Roles       []string
+	Permissions []string
+	// Claims are anything else Authenticator knows about principal, example: JWT claims.
+	Claims map[string]interface{}
+}
+
+// Authenticator tells who sends the request.
+// It returns ErrNoCredentials when request has no credentials for it, and ErrInvalidCredentials (may be wrapped)
+// when they are rejected. Returned errors.Error is responded as is, and any other error means credentials can not
+// be checked, example: database is down, which is responded as errors.ErrorInternalServer.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc is adapter to use ordinary function as Authenticator.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// Challenger is optionally implemented by Authenticator to tell client how to authenticate,
+// it is sent as `WWW-Authenticate` header on 401, example: `Bearer realm="api"`.
+type Challenger interface {
+	Challenge() string
+}
+
+type chain []Authenticator
+
+// ChainAuthenticators tries authenticators in the given order until one of them finds its credentials,
+// example: accept both bearer token and API key.
+func ChainAuthenticators(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+func (c chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		principal, err := a.Authenticate(r)
+		if err == ErrNoCredentials {
+			continue
+		}
+
+		return principal, err
+	}
+
+	return nil, ErrNoCredentials
+}
+
+func (c chain) Challenge() string {
+	for _, a := range c {
+		if challenger, ok := a.(Challenger); ok {
+			return challenger.Challenge()
+		}
+	}
+
+	return ""
+}
+
+type principalCtxKey struct{}
+
+// ContextWithPrincipal returns copy of ctx carrying principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+// PrincipalFromContext returns principal put by Authentication.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalCtxKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// PrincipalID returns ID of principal put by Authentication, example: KeyByPrincipal(PrincipalID) to rate limit by it.
+func PrincipalID(ctx context.Context) (string, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	return principal.ID, true
+}
+
+// Authentication rejects request which Authenticator does not accept with errors.ErrorAuthentication (401),
+// otherwise it puts Principal into request context, see PrincipalFromContext.
+type Authentication struct {
+	authenticator Authenticator
+	optional      bool
+	errorEncoder  ErrorEncoder
+	errorHandler  func(r *http.Request, err error)
+}
+
+// NewAuthentication returns Authentication, example:
+//
+//	auth := middleware.NewAuthentication(middleware.ChainAuthenticators(bearer, apiKey))
+//	s.Middleware(auth.Handler)
+//
+// Errors are written by ErrorEncoder of the Server it is registered on, so status follows its Dictionary.
+// Error other than ErrNoCredentials, ErrInvalidCredentials and errors.Error means Authenticator fails,
+// example: its database is down, so it is responded as errors.ErrorInternalServer instead of 401,
+// see ErrorHandlerAuthenticationOption to log it.
+func NewAuthentication(authenticator Authenticator, opts ...AuthenticationOption) *Authentication {
+	a := &Authentication{
+		authenticator: authenticator,
+		errorEncoder:  defaultErrorEncoder(http.StatusUnauthorized),
+		errorHandler:  func(r *http.Request, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+type AuthenticationOption func(*Authentication)
+
+// OptionalAuthenticationOption lets request without credentials through without principal,
+// while wrong credentials are still rejected.
+func OptionalAuthenticationOption(optional bool) AuthenticationOption {
+	return func(a *Authentication) {
+		a.optional = optional
+	}
+}
+
+// ErrorEncoderAuthenticationOption replaces ErrorEncoder carried by request ctx, see ContextWithErrorEncoder.
+func ErrorEncoderAuthenticationOption(errorEncoder ErrorEncoder) AuthenticationOption {
+	return func(a *Authentication) {
+		a.errorEncoder = errorEncoder
+	}
+}
+
+// ErrorHandlerAuthenticationOption is an option to get notified when Authenticator fails, example: to log it.
+// Rejected credentials are not reported.
+func ErrorHandlerAuthenticationOption(fn func(r *http.Request, err error)) AuthenticationOption {
+	return func(a *Authentication) {
+		a.errorHandler = fn
+	}
+}
+
+func (a *Authentication) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.authenticator.Authenticate(r)
+		if err == ErrNoCredentials && a.optional {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err != nil || principal == nil {
+			a.reject(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+	})
+}
+
+func (a *Authentication) reject(w http.ResponseWriter, r *http.Request, err error) {
+	_, isBusiness := err.(*errors.Error)
+	if err != nil && !isBusiness && !stderrors.Is(err, ErrNoCredentials) && !stderrors.Is(err, ErrInvalidCredentials) {
+		// credentials can not be checked at all, it is not client fault
+		a.errorHandler(r, err)
+		TagSpanError(r.Context(), err)
+		RecordOTelError(r.Context(), err, http.StatusInternalServerError)
+		a.errorEncoder(w, r, errors.ErrorInternalServer)
+		return
+	}
+
+	if challenger, ok := a.authenticator.(Challenger); ok {
+		if challenge := challenger.Challenge(); challenge != "" {
+			w.Header().Set("WWW-Authenticate", challenge)
+		}
+	}
+
+	// do not tell client why, besides what Authenticator chooses to
+	e, ok := err.(*errors.Error)
+	if !ok {
+		e = errors.ErrorAuthentication
+	}
+
+	a.errorEncoder(w, r, e)
+}
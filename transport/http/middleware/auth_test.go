@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// failingAuthenticator rejects every request with err.
+type failingAuthenticator struct {
+	err error
+}
+
+func (a failingAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	return nil, a.err
+}
+
+func (a failingAuthenticator) Challenge() string {
+	return `Bearer realm="api"`
+}
+
+func TestAuthenticationRejects(t *testing.T) {
+	outage := stderrors.New("db: connection refused")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantChallenge bool
+		wantReported  error
+	}{
+		{"no credentials", ErrNoCredentials, http.StatusUnauthorized, true, nil},
+		{"invalid credentials", ErrInvalidCredentials, http.StatusUnauthorized, true, nil},
+		{"wrapped invalid credentials", fmt.Errorf("%w: bad signature", ErrInvalidCredentials), http.StatusUnauthorized, true, nil},
+		{"business error", errors.ErrorForbidden, http.StatusUnauthorized, true, nil},
+		{"authenticator failure", outage, http.StatusInternalServerError, false, outage},
+		{"wrapped authenticator failure", fmt.Errorf("lookup: %w", outage), http.StatusInternalServerError, false, outage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported error
+			auth := NewAuthentication(failingAuthenticator{tt.err},
+				ErrorHandlerAuthenticationOption(func(r *http.Request, err error) { reported = err }),
+			)
+
+			w := httptest.NewRecorder()
+			auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("rejected request reaches the handler")
+			})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("WWW-Authenticate") != ""; got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want challenge %v", w.Header().Get("WWW-Authenticate"), tt.wantChallenge)
+			}
+			if !stderrors.Is(reported, tt.wantReported) || (tt.wantReported == nil && reported != nil) {
+				t.Errorf("reported = %v, want %v", reported, tt.wantReported)
+			}
+		})
+	}
+}
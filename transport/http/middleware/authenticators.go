@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BearerAuthenticator authenticates `Authorization: Bearer <token>` header, the token is checked by validate.
+type BearerAuthenticator struct {
+	realm    string
+	validate func(ctx context.Context, token string) (*Principal, error)
+}
+
+// NewBearerAuthenticator returns BearerAuthenticator, validate returns ErrInvalidCredentials (or errors.Error)
+// when token is rejected.
+func NewBearerAuthenticator(realm string, validate func(ctx context.Context, token string) (*Principal, error)) *BearerAuthenticator {
+	return &BearerAuthenticator{
+		realm:    realm,
+		validate: validate,
+	}
+}
+
+func (a *BearerAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := authorization(r, "Bearer")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	if token == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	principal, err := a.validate(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if principal.Scheme == "" {
+		principal.Scheme = "Bearer"
+	}
+
+	return principal, nil
+}
+
+func (a *BearerAuthenticator) Challenge() string {
+	return fmt.Sprintf("Bearer realm=%q", a.realm)
+}
+
+// APIKeyAuthenticator authenticates API key sent on header, or query when it is allowed.
+type APIKeyAuthenticator struct {
+	header string
+	query  string
+	lookup func(ctx context.Context, key string) (*Principal, error)
+}
+
+// NewAPIKeyAuthenticator returns APIKeyAuthenticator reading `X-API-Key` header, lookup returns ErrInvalidCredentials
+// (or errors.Error) when key is unknown. See StaticAPIKeys for fixed keys.
+func NewAPIKeyAuthenticator(lookup func(ctx context.Context, key string) (*Principal, error), opts ...APIKeyOption) *APIKeyAuthenticator {
+	a := &APIKeyAuthenticator{
+		header: "X-API-Key",
+		lookup: lookup,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+type APIKeyOption func(*APIKeyAuthenticator)
+
+// HeaderAPIKeyOption replaces header name, empty means header is not read.
+func HeaderAPIKeyOption(name string) APIKeyOption {
+	return func(a *APIKeyAuthenticator) {
+		a.header = name
+	}
+}
+
+// QueryAPIKeyOption allows key sent on querystring parameter name, example: `api_key`.
+// Header still takes precedence. Keep in mind URL usually ends up in logs.
+func QueryAPIKeyOption(name string) APIKeyOption {
+	return func(a *APIKeyAuthenticator) {
+		a.query = name
+	}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	var key string
+	if a.header != "" {
+		key = r.Header.Get(a.header)
+	}
+
+	if key == "" && a.query != "" {
+		key = r.URL.Query().Get(a.query)
+	}
+
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	principal, err := a.lookup(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if principal.Scheme == "" {
+		principal.Scheme = "APIKey"
+	}
+
+	return principal, nil
+}
+
+// StaticAPIKeys returns lookup of NewAPIKeyAuthenticator for fixed keys, example: loaded from config.
+// Keys are compared in constant time, so they can not be guessed by timing.
+func StaticAPIKeys(keys map[string]Principal) func(ctx context.Context, key string) (*Principal, error) {
+	return func(ctx context.Context, key string) (*Principal, error) {
+		var found *Principal
+		for k, principal := range keys {
+			principal := principal
+			// keep comparing after found, so timing does not tell position of the key
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				found = &principal
+			}
+		}
+
+		if found == nil {
+			return nil, ErrInvalidCredentials
+		}
+
+		return found, nil
+	}
+}
+
+// BasicAuthenticator authenticates HTTP basic authentication, username and password are checked by verify.
+type BasicAuthenticator struct {
+	realm  string
+	verify func(ctx context.Context, username, password string) (*Principal, error)
+}
+
+// NewBasicAuthenticator returns BasicAuthenticator, verify returns ErrInvalidCredentials (or errors.Error)
+// when they are wrong. Only use it over TLS, since password is sent on every request.
+func NewBasicAuthenticator(realm string, verify func(ctx context.Context, username, password string) (*Principal, error)) *BasicAuthenticator {
+	return &BasicAuthenticator{
+		realm:  realm,
+		verify: verify,
+	}
+}
+
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if _, ok := authorization(r, "Basic"); !ok {
+		return nil, ErrNoCredentials
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	principal, err := a.verify(r.Context(), username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if principal.Scheme == "" {
+		principal.Scheme = "Basic"
+	}
+
+	return principal, nil
+}
+
+func (a *BasicAuthenticator) Challenge() string {
+	return fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", a.realm)
+}
+
+// authorization returns credentials of `Authorization` header when it uses scheme, scheme is case-insensitive.
+func authorization(r *http.Request, scheme string) (string, bool) {
+	value := r.Header.Get("Authorization")
+	if len(value) < len(scheme) || !strings.EqualFold(value[:len(scheme)], scheme) {
+		return "", false
+	}
+
+	rest := value[len(scheme):]
+	if rest != "" && rest[0] != ' ' {
+		// another scheme sharing the prefix
+		return "", false
+	}
+
+	return strings.TrimSpace(rest), true
+}
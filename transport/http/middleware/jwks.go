@@ -0,0 +1,336 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey is returned by KeySet when there is no key with the given `kid`.
+var ErrUnknownKey = stderrors.New("middleware: unknown key")
+
+// KeySet returns key to verify JWT signed by key with `kid`, kid may be empty when token does not have it.
+// Returned key is one of *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or []byte (HMAC secret).
+type KeySet interface {
+	Key(ctx context.Context, kid string) (interface{}, error)
+}
+
+// StaticKeySet is KeySet of fixed keys by `kid`, key with empty kid is used when token does not have it.
+// Example: StaticKeySet{"": []byte(secret)} for HS256 with a shared secret.
+type StaticKeySet map[string]interface{}
+
+func (s StaticKeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+	return keyByID(s, kid)
+}
+
+// keyByID returns key of kid, when kid is empty and there is only one key, that key is used.
+func keyByID(keys map[string]interface{}, kid string) (interface{}, error) {
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+// jwk is JSON Web Key (RFC 7517), only public parts are read, besides `k` of symmetric key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses JSON Web Key Set into keys by `kid`.
+// Keys for encryption (`use` is `enc`) and of unsupported type or curve are skipped.
+func ParseJWKS(data []byte) (StaticKeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("middleware: invalid JWKS: %w", err)
+	}
+
+	keys := StaticKeySet{}
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+
+		key, err := k.key()
+		if err != nil {
+			return nil, fmt.Errorf("middleware: invalid JWK %q: %w", k.Kid, err)
+		}
+
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+func (k jwk) key() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			// unsupported curve, example: secp256k1, skipped as unsupported type
+			return nil, nil
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %q", k.Crv)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		// X25519 is for key agreement, not signature
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// LoadJWKSFile reads local JWKS file, example: mounted from secret.
+func LoadJWKSFile(path string) (StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
+
+// RemoteJWKS is KeySet fetched from JWKS URL of the issuer, example: `https://issuer/.well-known/jwks.json`.
+// Keys are cached for TTL, and fetched again right away when token has unknown `kid`, since issuer may have rotated
+// its keys. To protect the issuer from tokens with made-up kid, that refresh happens once every MinRefreshInterval.
+// Fetch does not follow ctx of the request, so a canceled request does not fail the fetch for every other request.
+type RemoteJWKS struct {
+	url                string
+	client             *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+	fetchTimeout       time.Duration
+
+	mu          sync.RWMutex
+	keys        StaticKeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	err         error
+	// serializes fetch, so concurrent requests with unknown kid do not fetch all at once
+	fetchMu sync.Mutex
+}
+
+type RemoteJWKSOption func(*RemoteJWKS)
+
+func HTTPClientJWKSOption(client *http.Client) RemoteJWKSOption {
+	return func(j *RemoteJWKS) {
+		j.client = client
+	}
+}
+
+// TTLJWKSOption sets how long keys are cached, default is 1 hour.
+func TTLJWKSOption(ttl time.Duration) RemoteJWKSOption {
+	return func(j *RemoteJWKS) {
+		j.ttl = ttl
+	}
+}
+
+// MinRefreshIntervalJWKSOption sets how often keys may be fetched because of unknown kid, default is 1 minute.
+func MinRefreshIntervalJWKSOption(interval time.Duration) RemoteJWKSOption {
+	return func(j *RemoteJWKS) {
+		j.minRefreshInterval = interval
+	}
+}
+
+// FetchTimeoutJWKSOption sets how long a fetch may take, default is 10 seconds.
+func FetchTimeoutJWKSOption(timeout time.Duration) RemoteJWKSOption {
+	return func(j *RemoteJWKS) {
+		j.fetchTimeout = timeout
+	}
+}
+
+// NewRemoteJWKS returns RemoteJWKS, keys are fetched lazily on the first token.
+func NewRemoteJWKS(url string, opts ...RemoteJWKSOption) *RemoteJWKS {
+	j := &RemoteJWKS{
+		url:                url,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		ttl:                time.Hour,
+		minRefreshInterval: time.Minute,
+		fetchTimeout:       10 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	return j
+}
+
+func (j *RemoteJWKS) Key(ctx context.Context, kid string) (interface{}, error) {
+	j.mu.RLock()
+	keys, fetchedAt, attemptedAt := j.keys, j.fetchedAt, j.attemptedAt
+	j.mu.RUnlock()
+
+	if keys != nil && time.Since(fetchedAt) <= j.ttl {
+		if key, err := keyByID(keys, kid); err == nil {
+			return key, nil
+		}
+	}
+
+	// keys are stale, or unknown kid may be a newly rotated key
+	if time.Since(attemptedAt) > j.minRefreshInterval {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			j.refresh(attemptedAt)
+		}()
+
+		// caller may give up, the fetch still completes for the others
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	// when fetch fails, stale keys are still better than rejecting every token
+	if j.keys == nil {
+		return nil, j.err
+	}
+
+	return keyByID(j.keys, kid)
+}
+
+// refresh fetches keys, unless they are already attempted after seen by the caller.
+func (j *RemoteJWKS) refresh(seen time.Time) {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+
+	j.mu.RLock()
+	attemptedAt := j.attemptedAt
+	j.mu.RUnlock()
+	if attemptedAt.After(seen) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), j.fetchTimeout)
+	defer cancel()
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.attemptedAt = time.Now()
+	j.err = err
+	if err == nil {
+		j.keys = keys
+		j.fetchedAt = j.attemptedAt
+	}
+}
+
+func (j *RemoteJWKS) fetch(ctx context.Context) (StaticKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("middleware: fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("middleware: fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	// JWKS is small, anything bigger is not one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("middleware: fetch JWKS: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
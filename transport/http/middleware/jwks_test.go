@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testJWKS = `{"keys":[{"kty":"oct","kid":"k1","k":"c2VjcmV0"}]}`
+
+func TestRemoteJWKSCanceledCallerDoesNotFailOthers(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		w.Write([]byte(testJWKS))
+	}))
+	defer srv.Close()
+
+	jwks := NewRemoteJWKS(srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := jwks.Key(ctx, "k1"); err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	// the fetch started by canceled caller is still running, fresh caller waits for it instead of failing
+	close(release)
+	key, err := jwks.Key(context.Background(), "k1")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if string(key.([]byte)) != "secret" {
+		t.Fatalf("key = %q, want secret", key)
+	}
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("fetches = %d, want 1", n)
+	}
+}
+
+func TestRemoteJWKSRefreshOnUnknownKid(t *testing.T) {
+	var jwks atomic.Value
+	jwks.Store(testJWKS)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(jwks.Load().(string)))
+	}))
+	defer srv.Close()
+
+	set := NewRemoteJWKS(srv.URL, MinRefreshIntervalJWKSOption(time.Millisecond))
+	if _, err := set.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	// issuer rotated its key
+	jwks.Store(`{"keys":[{"kty":"oct","kid":"k2","k":"c2VjcmV0"}]}`)
+	time.Sleep(2 * time.Millisecond)
+	if _, err := set.Key(context.Background(), "k2"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+func TestParseJWKSSkipsUnsupportedKeys(t *testing.T) {
+	data := `{"keys":[
+		{"kty":"oct","kid":"k1","k":"c2VjcmV0"},
+		{"kty":"EC","kid":"k2","crv":"secp256k1","x":"AA","y":"AA"},
+		{"kty":"OKP","kid":"k3","crv":"X25519","x":"AA"},
+		{"kty":"oct","kid":"k4","use":"enc","k":"c2VjcmV0"},
+		{"kty":"unknown","kid":"k5"}
+	]}`
+
+	keys, err := ParseJWKS([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if len(keys) != 1 || keys["k1"] == nil {
+		t.Fatalf("keys = %v, want only k1", keys)
+	}
+}
+
+func TestParseJWKSRejectsInvalidKey(t *testing.T) {
+	data := `{"keys":[{"kty":"EC","kid":"k1","crv":"P-256","x":"AQ","y":"AQ"}]}`
+
+	if _, err := ParseJWKS([]byte(data)); err == nil {
+		t.Fatal("want error for point which is not on curve")
+	}
+}
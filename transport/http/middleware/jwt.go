@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// DefaultJWTAlgorithms are asymmetric algorithms accepted by JWTAuthenticator by default.
+// HS256, HS384 and HS512 must be enabled explicitly with AlgorithmsJWTOption, since they need shared secret.
+var DefaultJWTAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// JWTAuthenticator is Authenticator verifying JWT sent as bearer token.
+// Token is verified by key from KeySet, then its `exp`, `nbf`, `iss` and `aud` claims are validated.
+// Expired token is rejected with errors.ErrorTokenExpired, so client knows it should refresh the token,
+// any other invalid token is rejected with errors.ErrorAuthentication.
+//
+// Example:
+//
+//	jwks := middleware.NewRemoteJWKS("https://issuer.example.com/.well-known/jwks.json")
+//	auth := middleware.NewAuthentication(middleware.NewJWTAuthenticator(jwks,
+//		middleware.IssuerJWTOption("https://issuer.example.com"),
+//		middleware.AudienceJWTOption("my-api"),
+//	))
+type JWTAuthenticator struct {
+	keys       KeySet
+	algorithms []string
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	realm      string
+	principal  func(claims jwt.MapClaims) (*Principal, error)
+}
+
+func NewJWTAuthenticator(keys KeySet, opts ...JWTOption) *JWTAuthenticator {
+	a := &JWTAuthenticator{
+		keys:       keys,
+		algorithms: DefaultJWTAlgorithms,
+		principal:  principalFromClaims,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+type JWTOption func(*JWTAuthenticator)
+
+// AlgorithmsJWTOption replaces accepted algorithms, example: "HS256" with StaticKeySet of the secret.
+func AlgorithmsJWTOption(algorithms ...string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.algorithms = algorithms
+	}
+}
+
+// IssuerJWTOption requires `iss` claim to be iss.
+func IssuerJWTOption(iss string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.issuer = iss
+	}
+}
+
+// AudienceJWTOption requires `aud` claim to contain aud.
+func AudienceJWTOption(aud string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.audience = aud
+	}
+}
+
+// LeewayJWTOption tolerates clock skew between issuer and this service when validating `exp`, `nbf` and `iat`.
+func LeewayJWTOption(leeway time.Duration) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.leeway = leeway
+	}
+}
+
+// RealmJWTOption sets realm of `WWW-Authenticate` header.
+func RealmJWTOption(realm string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.realm = realm
+	}
+}
+
+// PrincipalJWTOption replaces how Principal is made of claims.
+// By default ID is `sub`, Roles is `roles`, and Permissions is `permissions`, or `scope` split by space.
+func PrincipalJWTOption(fn func(claims jwt.MapClaims) (*Principal, error)) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.principal = fn
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	raw, ok := authorization(r, "Bearer")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(a.algorithms),
+		jwt.WithLeeway(a.leeway),
+		jwt.WithExpirationRequired(),
+	}
+
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+
+	if a.audience != "" {
+		opts = append(opts, jwt.WithAudience(a.audience))
+	}
+
+	ctx := r.Context()
+	claims := jwt.MapClaims{}
+	var keyErr error
+	_, err := jwt.NewParser(opts...).ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := a.keys.Key(ctx, kid)
+		keyErr = err
+		return key, err
+	})
+	if err != nil {
+		// KeySet is unavailable, example: JWKS can not be fetched, so the token can not be checked at all
+		if keyErr != nil && !stderrors.Is(keyErr, ErrUnknownKey) {
+			return nil, keyErr
+		}
+
+		if stderrors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errors.ErrorTokenExpired
+		}
+
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	principal, err := a.principal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if principal.Scheme == "" {
+		principal.Scheme = "Bearer"
+	}
+
+	if principal.Claims == nil {
+		principal.Claims = claims
+	}
+
+	return principal, nil
+}
+
+func (a *JWTAuthenticator) Challenge() string {
+	if a.realm == "" {
+		return "Bearer"
+	}
+
+	return fmt.Sprintf("Bearer realm=%q", a.realm)
+}
+
+func principalFromClaims(claims jwt.MapClaims) (*Principal, error) {
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidCredentials)
+	}
+
+	principal := &Principal{
+		ID:          sub,
+		Roles:       stringsClaim(claims["roles"]),
+		Permissions: stringsClaim(claims["permissions"]),
+		Claims:      claims,
+	}
+
+	if len(principal.Permissions) == 0 {
+		principal.Permissions = stringsClaim(claims["scope"])
+	}
+
+	return principal, nil
+}
+
+// stringsClaim reads claim of either array of string, or string separated by space (as OAuth2 `scope`).
+func stringsClaim(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+// ClaimsFromContext returns claims of principal authenticated by JWTAuthenticator,
+// example: `claims["tenant_id"]`.
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	return jwt.MapClaims(principal.Claims), principal.Claims != nil
+}
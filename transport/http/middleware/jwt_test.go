@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// newTestJWKS serves public part of key as JWKS under kid.
+func newTestJWKS(t *testing.T, kid string, key *ecdsa.PrivateKey) *httptest.Server {
+	t.Helper()
+
+	enc := base64.RawURLEncoding
+	body := fmt.Sprintf(`{"keys":[{"kty":"EC","kid":%q,"crv":"P-256","x":%q,"y":%q}]}`,
+		kid, enc.EncodeToString(key.X.FillBytes(make([]byte, 32))), enc.EncodeToString(key.Y.FillBytes(make([]byte, 32))))
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+}
+
+func signES256(t *testing.T, kid string, key *ecdsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return r
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := newTestJWKS(t, "k1", key)
+	defer srv.Close()
+
+	a := NewJWTAuthenticator(NewRemoteJWKS(srv.URL),
+		IssuerJWTOption("https://issuer.example.com"),
+		AudienceJWTOption("api"),
+		LeewayJWTOption(time.Minute),
+	)
+
+	now := time.Now()
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":   "u1",
+			"iss":   "https://issuer.example.com",
+			"aud":   "api",
+			"exp":   now.Add(time.Hour).Unix(),
+			"roles": []string{"admin"},
+			"scope": "orders:read orders:write",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", signES256(t, "k1", key, claims(nil)), nil},
+		{"expired within leeway", signES256(t, "k1", key, claims(jwt.MapClaims{"exp": now.Add(-30 * time.Second).Unix()})), nil},
+		{"expired", signES256(t, "k1", key, claims(jwt.MapClaims{"exp": now.Add(-time.Hour).Unix()})), errors.ErrorTokenExpired},
+		{"without exp", signES256(t, "k1", key, claims(jwt.MapClaims{"exp": nil})), ErrInvalidCredentials},
+		{"not yet valid", signES256(t, "k1", key, claims(jwt.MapClaims{"nbf": now.Add(time.Hour).Unix()})), ErrInvalidCredentials},
+		{"other issuer", signES256(t, "k1", key, claims(jwt.MapClaims{"iss": "https://evil.example.com"})), ErrInvalidCredentials},
+		{"other audience", signES256(t, "k1", key, claims(jwt.MapClaims{"aud": "other"})), ErrInvalidCredentials},
+		{"without sub", signES256(t, "k1", key, claims(jwt.MapClaims{"sub": nil})), ErrInvalidCredentials},
+		{"signed by other key", signES256(t, "k1", other, claims(nil)), ErrInvalidCredentials},
+		{"unknown kid", signES256(t, "k2", key, claims(nil)), ErrInvalidCredentials},
+		{"malformed", "not.a.jwt", ErrInvalidCredentials},
+		{"no token", "", ErrNoCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Authenticate(bearerRequest(tt.token))
+			if tt.wantErr != nil {
+				if !stderrors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if principal.ID != "u1" || principal.Scheme != "Bearer" ||
+				strings.Join(principal.Roles, ",") != "admin" ||
+				strings.Join(principal.Permissions, ",") != "orders:read,orders:write" {
+				t.Errorf("principal = %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorAlgorithms(t *testing.T) {
+	secret := []byte("secret")
+	keys := StaticKeySet{"": secret}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(time.Hour).Unix()})
+	raw, _ := token.SignedString(secret)
+
+	// symmetric algorithms are rejected unless enabled, so public key can never be used as HMAC secret
+	if _, err := NewJWTAuthenticator(keys).Authenticate(bearerRequest(raw)); !stderrors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("default algorithms: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	if _, err := NewJWTAuthenticator(keys, AlgorithmsJWTOption("HS256")).Authenticate(bearerRequest(raw)); err != nil {
+		t.Errorf("HS256 enabled: unexpected err: %v", err)
+	}
+}
+
+func TestAuthenticationRespondsExpiredToken(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := newTestJWKS(t, "k1", key)
+	defer srv.Close()
+
+	auth := NewAuthentication(NewJWTAuthenticator(NewRemoteJWKS(srv.URL), RealmJWTOption("api")))
+	h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expired token reaches the handler")
+	}))
+
+	raw := signES256(t, "k1", key, jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(-time.Hour).Unix()})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, bearerRequest(raw))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"code":806`) {
+		t.Errorf("body = %s, want ErrorTokenExpired", w.Body.String())
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="api"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
+
+func TestJWTAuthenticatorKeySetUnavailable(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	auth := NewAuthentication(NewJWTAuthenticator(NewRemoteJWKS(srv.URL)))
+	h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	raw := signES256(t, "k1", key, jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(time.Hour).Unix()})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, bearerRequest(raw))
+
+	// issuer outage is not client fault
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+}
@@ -0,0 +1,306 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dee-el/go-fw/config"
+	"github.com/dee-el/go-fw/errors"
+)
+
+// Maintenance responds errors.ErrorMaintenance (503) with `Retry-After` header while maintenance mode is on,
+// either toggled at runtime (see Enable) or within scheduled window (see Schedule).
+// Allowed paths, example: health check and admin endpoints, and bypassed IPs are still served.
+type Maintenance struct {
+	mu           sync.RWMutex
+	enabled      bool
+	start        time.Time
+	end          time.Time
+	retryAfter   time.Duration
+	allowedPaths []string
+	bypass       []*net.IPNet
+	// set by ApplyConfig, kept apart so reloading config does not drop those set by options
+	cfgAllowedPaths []string
+	cfgBypass       []*net.IPNet
+
+	errorEncoder ErrorEncoder
+}
+
+// MaintenanceStatus is snapshot of Maintenance.
+type MaintenanceStatus struct {
+	// Enabled is the runtime toggle.
+	Enabled bool `json:"enabled"`
+	// Active tells whether requests are rejected now, either by Enabled or the scheduled window.
+	Active bool       `json:"active"`
+	Start  *time.Time `json:"start,omitempty"`
+	End    *time.Time `json:"end,omitempty"`
+}
+
+func NewMaintenance(opts ...MaintenanceOption) *Maintenance {
+	m := &Maintenance{
+		retryAfter:   5 * time.Minute,
+		errorEncoder: defaultErrorEncoder(http.StatusServiceUnavailable),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+type MaintenanceOption func(*Maintenance)
+
+// AllowedPathsMaintenanceOption adds paths served during maintenance, path ending with `/*` allows everything under it,
+// example: `/health` and `/admin/*`.
+func AllowedPathsMaintenanceOption(paths ...string) MaintenanceOption {
+	return func(m *Maintenance) {
+		m.allowedPaths = append(m.allowedPaths, paths...)
+	}
+}
+
+// BypassIPsMaintenanceOption adds client IPs or CIDRs served during maintenance, example: office network to verify
+// the migration. Client IP is read from RemoteAddr, never from forwarded headers, so behind proxy RealIP middleware
+// trusting that proxy must run before, on Server set WithTrustedProxies. It panics on invalid IP,
+// so misconfiguration is found at startup.
+func BypassIPsMaintenanceOption(ips ...string) MaintenanceOption {
+	return func(m *Maintenance) {
+		bypass, err := parseIPNets(ips)
+		if err != nil {
+			panic(err)
+		}
+
+		m.bypass = append(m.bypass, bypass...)
+	}
+}
+
+// RetryAfterMaintenanceOption sets `Retry-After` when maintenance has no scheduled end, default is 5 minutes.
+func RetryAfterMaintenanceOption(d time.Duration) MaintenanceOption {
+	return func(m *Maintenance) {
+		m.retryAfter = d
+	}
+}
+
+// ErrorEncoderMaintenanceOption replaces ErrorEncoder carried by request ctx, see ContextWithErrorEncoder.
+func ErrorEncoderMaintenanceOption(errorEncoder ErrorEncoder) MaintenanceOption {
+	return func(m *Maintenance) {
+		m.errorEncoder = errorEncoder
+	}
+}
+
+// ConfigMaintenanceOption sets up maintenance from cfg, see ApplyConfig.
+// It panics on invalid config, so misconfiguration is found at startup.
+func ConfigMaintenanceOption(cfg config.MaintenanceConfig) MaintenanceOption {
+	return func(m *Maintenance) {
+		if err := m.ApplyConfig(cfg); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Enable turns maintenance mode on, until Disable is called.
+func (m *Maintenance) Enable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.enabled = true
+}
+
+// Disable turns runtime toggle off, scheduled window still applies.
+func (m *Maintenance) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.enabled = false
+}
+
+// Schedule turns maintenance mode on between start and end, zero end means until the window is cleared.
+// Zero start and end clear the window.
+func (m *Maintenance) Schedule(start, end time.Time) error {
+	if !end.IsZero() && !end.After(start) {
+		return fmt.Errorf("middleware: maintenance end %s must be after start %s", end, start)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.start, m.end = start, end
+	return nil
+}
+
+// ApplyConfig replaces toggle, window, allowed paths and bypassed IPs with cfg at once, example: on config reload.
+// Allowed paths and bypassed IPs set by options are kept. Invalid cfg is rejected as a whole, so current state is kept.
+func (m *Maintenance) ApplyConfig(cfg config.MaintenanceConfig) error {
+	bypass, err := parseIPNets(cfg.BypassIPs)
+	if err != nil {
+		return err
+	}
+
+	var start, end time.Time
+	if cfg.Start != "" {
+		if start, err = time.Parse(time.RFC3339, cfg.Start); err != nil {
+			return fmt.Errorf("middleware: invalid maintenance start: %w", err)
+		}
+	}
+
+	if cfg.End != "" {
+		if end, err = time.Parse(time.RFC3339, cfg.End); err != nil {
+			return fmt.Errorf("middleware: invalid maintenance end: %w", err)
+		}
+
+		if !end.After(start) {
+			return fmt.Errorf("middleware: maintenance end %s must be after start %s", cfg.End, cfg.Start)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.enabled = cfg.Enabled
+	m.start, m.end = start, end
+	m.cfgAllowedPaths = cfg.AllowedPaths
+	m.cfgBypass = bypass
+	if cfg.RetryAfterInSecond > 0 {
+		m.retryAfter = time.Second * time.Duration(cfg.RetryAfterInSecond)
+	}
+
+	return nil
+}
+
+// Status returns current state of maintenance.
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := MaintenanceStatus{
+		Enabled: m.enabled,
+		Active:  m.active(time.Now()),
+	}
+
+	if !m.start.IsZero() {
+		start := m.start
+		status.Start = &start
+	}
+
+	if !m.end.IsZero() {
+		end := m.end
+		status.End = &end
+	}
+
+	return status
+}
+
+// active must be called with lock held.
+func (m *Maintenance) active(now time.Time) bool {
+	if m.enabled {
+		return true
+	}
+
+	if m.start.IsZero() && m.end.IsZero() {
+		return false
+	}
+
+	return !now.Before(m.start) && (m.end.IsZero() || now.Before(m.end))
+}
+
+func (m *Maintenance) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+
+		m.mu.RLock()
+		active := m.active(now) && !m.allowed(r)
+		retryAfter := m.retryAfter
+		// scheduled end is more accurate than the guess
+		if !m.enabled && !m.end.IsZero() {
+			retryAfter = m.end.Sub(now)
+		}
+		m.mu.RUnlock()
+
+		if !active {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		m.errorEncoder(w, r, errors.ErrorMaintenance)
+	})
+}
+
+// allowed must be called with lock held.
+func (m *Maintenance) allowed(r *http.Request) bool {
+	if allowedPath(r.URL.Path, m.allowedPaths) || allowedPath(r.URL.Path, m.cfgAllowedPaths) {
+		return true
+	}
+
+	if len(m.bypass) == 0 && len(m.cfgBypass) == 0 {
+		return false
+	}
+
+	ip := remoteIP(r)
+	if ip == nil {
+		return false
+	}
+
+	return containsIP(m.bypass, ip) || containsIP(m.cfgBypass, ip)
+}
+
+func allowedPath(path string, allowed []string) bool {
+	for _, p := range allowed {
+		if prefix, ok := strings.CutSuffix(p, "/*"); ok {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+			continue
+		}
+
+		if path == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIPNets parses IPs and CIDRs, single IP is treated as CIDR of itself.
+func parseIPNets(ips []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(ips))
+	for _, s := range ips {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("middleware: invalid IP %q", s)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("middleware: invalid CIDR %q: %w", s, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
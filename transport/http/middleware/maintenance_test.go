@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMaintenanceBypass(t *testing.T) {
+	m := NewMaintenance(
+		AllowedPathsMaintenanceOption("/health", "/admin/*"),
+		BypassIPsMaintenanceOption("10.0.0.0/8", "::1"),
+	)
+	m.Enable()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := m.Handler(ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		header     http.Header
+		want       int
+	}{
+		{"rejected", "/orders", "192.0.2.1:1234", nil, http.StatusServiceUnavailable},
+		{"allowed path", "/health", "192.0.2.1:1234", nil, http.StatusOK},
+		{"allowed prefix", "/admin/maintenance", "192.0.2.1:1234", nil, http.StatusOK},
+		{"prefix is not partial", "/administrator", "192.0.2.1:1234", nil, http.StatusServiceUnavailable},
+		{"bypassed IP", "/orders", "10.1.2.3:1234", nil, http.StatusOK},
+		{"bypassed IP set by RealIP", "/orders", "10.1.2.3", nil, http.StatusOK},
+		{"bypassed IPv6", "/orders", "[::1]:1234", nil, http.StatusOK},
+		{"spoofed X-Forwarded-For", "/orders", "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"10.1.2.3"}}, http.StatusServiceUnavailable},
+		{"spoofed X-Real-IP", "/orders", "192.0.2.1:1234", http.Header{"X-Real-Ip": {"10.1.2.3"}}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+
+			if tt.want == http.StatusServiceUnavailable && w.Header().Get("Retry-After") != "300" {
+				t.Fatalf("Retry-After = %q, want 300", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
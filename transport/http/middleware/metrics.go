@@ -12,6 +12,10 @@ import (
 	"github.com/dee-el/go-fw/metrics"
 )
 
+// UnmatchedRoute is `path` label of request matching no registered route, instead of its URL path,
+// so scanning random paths does not create a new series for each of them.
+const UnmatchedRoute = "unmatched"
+
 type Metrics struct {
 	// all metrics will be recorded by this.
 	recorder metrics.InboundHTTPRecorder
@@ -56,21 +60,29 @@ func (i *Metrics) Handler(next http.Handler) http.Handler {
 		next.ServeHTTP(rw, r)
 		defer func() {
 			// since using chi as template mux http, utilize chi context to get regexed pattern path
-			ctx := chi.RouteContext(r.Context())
-			path := ctx.RoutePattern()
+			var path string
+			if ctx := chi.RouteContext(r.Context()); ctx != nil {
+				path = ctx.RoutePattern()
+			}
 
 			// if URL path called not the registered one, it will be empty
 			// it can be mean DDoS attack happened or client hit deprecated routes
 			if path == "" {
-				path = r.URL.Path
+				path = UnmatchedRoute
 			}
 
 			method := r.Method
 
+			// handler which never writes anything is responded as 200 by net/http
+			status := rw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
 			var code string
-			code = strconv.Itoa(rw.Status())
+			code = strconv.Itoa(status)
 			if i.groupedStatus {
-				code = fmt.Sprintf("%dxx", rw.Status()/100)
+				code = fmt.Sprintf("%dxx", status/100)
 			}
 
 			duration := time.Since(now)
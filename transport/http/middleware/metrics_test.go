@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+)
+
+type pathRecorder struct {
+	mu    sync.Mutex
+	paths map[string]int
+}
+
+func (p *pathRecorder) Record(path, method, status string, duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.paths[path]++
+}
+
+func TestMetricsLabelsUnmatchedRoutes(t *testing.T) {
+	rec := &pathRecorder{paths: map[string]int{}}
+
+	r := chi.NewRouter()
+	r.Use(NewMetrics(rec).Handler)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/users/1", "/users/2", "/.env", "/wp-admin", "/admin/config.php"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	want := map[string]int{"/users/{id}": 2, UnmatchedRoute: 3}
+	if len(rec.paths) != len(want) {
+		t.Fatalf("paths = %v, want %v", rec.paths, want)
+	}
+	for path, n := range want {
+		if rec.paths[path] != n {
+			t.Errorf("paths[%q] = %d, want %d", path, rec.paths[path], n)
+		}
+	}
+}
+
+func TestMetricsWithoutRouteContext(t *testing.T) {
+	rec := &pathRecorder{paths: map[string]int{}}
+	h := NewMetrics(rec).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/anything", nil))
+
+	if rec.paths[UnmatchedRoute] != 1 {
+		t.Errorf("paths = %v, want one %q", rec.paths, UnmatchedRoute)
+	}
+}
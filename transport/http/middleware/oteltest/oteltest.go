@@ -0,0 +1,29 @@
+// Package oteltest provides in-memory OpenTelemetry tracing, so spans created by OTelTracing can be asserted on tests.
+package oteltest
+
+import (
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Exporter keeps every ended span in memory.
+type Exporter = tracetest.InMemoryExporter
+
+// NewTracerProvider returns TracerProvider which exports synchronously into returned Exporter,
+// so spans are available right after the request is served.
+//
+// Example:
+//
+//	tp, exp := oteltest.NewTracerProvider()
+//	s := http.NewServer(http.WithOTelTracing(middleware.NewOTelTracing(tp)))
+//	// serve request ...
+//	spans := exp.GetSpans()
+func NewTracerProvider() (*sdktrace.TracerProvider, *Exporter) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exp),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+
+	return tp, exp
+}
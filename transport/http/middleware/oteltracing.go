@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	chi "github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/tracederr"
+)
+
+const otelInstrumentationName = "github.com/dee-el/go-fw/transport/http/middleware"
+
+// OTelTracing is like Tracing, but built on OpenTelemetry.
+// By default span context is propagated using W3C `traceparent` and `tracestate` headers.
+type OTelTracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func NewOTelTracing(tp trace.TracerProvider, opts ...OTelTracingOption) *OTelTracing {
+	t := &OTelTracing{
+		tracer:     tp.Tracer(otelInstrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+type OTelTracingOption func(*OTelTracing)
+
+// PropagatorOTelTracingOption replaces W3C trace context propagator, example: to add baggage.
+func PropagatorOTelTracingOption(p propagation.TextMapPropagator) OTelTracingOption {
+	return func(t *OTelTracing) {
+		t.propagator = p
+	}
+}
+
+type otelCtxKey struct{}
+
+// otelSpanState is shared between middleware and RecordOTelError within one request.
+type otelSpanState struct {
+	errRecorded bool
+}
+
+func (t *OTelTracing) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+
+		// route is not resolved yet here, span name will be renamed after
+		ctx, span := t.tracer.Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPSchemeKey.String(scheme),
+				semconv.HTTPTargetKey.String(r.URL.RequestURI()),
+				semconv.NetHostNameKey.String(r.Host),
+				semconv.HTTPUserAgentKey.String(r.UserAgent()),
+				semconv.HTTPClientIPKey.String(clientIP(r)),
+			),
+		)
+		defer span.End()
+
+		state := &otelSpanState{}
+		ctx = context.WithValue(ctx, otelCtxKey{}, state)
+
+		rw := chi_middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		// since using chi as template mux http, utilize chi context to get regexed pattern path
+		var path string
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			path = rctx.RoutePattern()
+		}
+		if path != "" {
+			span.SetName(r.Method + " " + path)
+			span.SetAttributes(semconv.HTTPRouteKey.String(path))
+		}
+
+		status := rw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+
+		// only 5xx is error for server span, unless Handler already recorded the error
+		if status >= http.StatusInternalServerError && !state.errRecorded {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// RecordOTelError records err on the active OpenTelemetry span of ctx, it does nothing when there is no span.
+// Handler calls this for every error it receives along with HTTP status err is responded with.
+// Every error is recorded as span event, but span status is set to error only for internal error or 5xx status,
+// so business errors, example: not found, do not show up as server failures.
+func RecordOTelError(ctx context.Context, err error, status int) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("error.kind", "internal")}
+	if e, ok := err.(*errors.Error); ok {
+		attrs = []attribute.KeyValue{
+			attribute.String("error.kind", "business"),
+			attribute.String("error.type", string(e.Type)),
+			attribute.Int("error.code", int(e.Code)),
+		}
+	}
+
+	span.SetAttributes(attrs...)
+
+	// same as TagSpanError, every wrapped error along with its stack frames
+	if _, ok := err.(*errors.Error); !ok {
+		stack, _ := json.Marshal(tracederr.PrintErrors(err, nil))
+		attrs = append(attrs, semconv.ExceptionStacktraceKey.String(string(stack)))
+	}
+	span.RecordError(err, trace.WithAttributes(attrs...))
+
+	if _, ok := err.(*errors.Error); !ok || status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if state, ok := ctx.Value(otelCtxKey{}).(*otelSpanState); ok {
+		state.errRecorded = true
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+
+	"github.com/dee-el/go-fw/transport/http/middleware/oteltest"
+)
+
+func TestOTelTracingClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"peer address", nil, "192.0.2.1:1234", "", "192.0.2.1"},
+		{"spoofed forwarded list", nil, "192.0.2.1:1234", "10.1.2.3, 198.51.100.7", "192.0.2.1"},
+		{"forwarded by trusted proxy", []string{"10.0.0.1"}, "10.0.0.1:1234", "10.1.2.3, 198.51.100.7", "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, exp := oteltest.NewTracerProvider()
+			h := NewRealIP(tt.trusted...).Handler(NewOTelTracing(tp).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			spans := exp.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+
+			var got string
+			for _, attr := range spans[0].Attributes {
+				if attr.Key == semconv.HTTPClientIPKey {
+					got = attr.Value.AsString()
+				}
+			}
+			if got != tt.want {
+				t.Errorf("%s = %q, want %q", semconv.HTTPClientIPKey, got, tt.want)
+			}
+		})
+	}
+}
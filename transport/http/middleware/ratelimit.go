@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// RateLimitAlgorithm decides how requests are counted within RateLimitPolicy.
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket refills Limit tokens every Window evenly, and allows bursts up to Burst tokens.
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow allows Limit requests within any Window, estimated from the current and previous fixed windows.
+	SlidingWindow
+)
+
+func (a RateLimitAlgorithm) String() string {
+	switch a {
+	case TokenBucket:
+		return "token_bucket"
+	case SlidingWindow:
+		return "sliding_window"
+	}
+
+	return "unknown"
+}
+
+// RateLimitPolicy is how many requests are allowed per key.
+type RateLimitPolicy struct {
+	Algorithm RateLimitAlgorithm
+	// Limit is how many requests allowed within Window.
+	Limit  int
+	Window time.Duration
+	// Burst is capacity of the bucket, only used by TokenBucket. By default will be Limit.
+	Burst int
+}
+
+// Validate returns error when p can not limit anything, example: zero Window.
+func (p RateLimitPolicy) Validate() error {
+	if p.Algorithm != TokenBucket && p.Algorithm != SlidingWindow {
+		return fmt.Errorf("middleware: unknown rate limit algorithm %d", p.Algorithm)
+	}
+
+	if p.Limit <= 0 {
+		return fmt.Errorf("middleware: rate limit must be positive, got %d", p.Limit)
+	}
+
+	if p.Window <= 0 {
+		return fmt.Errorf("middleware: rate limit window must be positive, got %s", p.Window)
+	}
+
+	return nil
+}
+
+// RateLimitResult is result of one request taken from RateLimitStore.
+type RateLimitResult struct {
+	Allowed bool
+	// Limit is the maximum requests, either Limit or Burst of the policy.
+	Limit     int
+	Remaining int
+	// Reset is how long until the quota is fully available again.
+	Reset time.Duration
+	// RetryAfter is how long until next request may be allowed, only set when it is not allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps counters of every key, so they can be shared by multiple instances, example: Redis.
+// Take counts one request of key and tells whether it is allowed under policy.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc returns key to limit request by, requests with the same key share the same quota.
+// Empty key means the request is not limited.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// KeyByIP limits by client IP, read from RemoteAddr, never from forwarded headers, so client can not dodge the limit
+// by sending different ones. Behind proxy, RealIP middleware trusting that proxy must run before,
+// on Server set WithTrustedProxies.
+func KeyByIP(r *http.Request) string {
+	if ip := remoteIP(r); ip != nil {
+		return "ip:" + ip.String()
+	}
+
+	return "ip:" + r.RemoteAddr
+}
+
+// KeyByRoute limits by route pattern, so every client shares the same quota of a route.
+// Route is resolved from chi, even when the middleware runs before routing.
+func KeyByRoute(r *http.Request) string {
+	return "route:" + r.Method + " " + routePattern(r)
+}
+
+// KeyByPrincipal limits by ID of authenticated principal returned by id, example: KeyByPrincipal(PrincipalID)
+// when it is registered after Authentication. Requests without principal are limited by IP instead.
+func KeyByPrincipal(id func(ctx context.Context) (string, bool)) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if principal, ok := id(r.Context()); ok && principal != "" {
+			return "principal:" + principal
+		}
+
+		return KeyByIP(r)
+	}
+}
+
+// RateLimit rejects requests over RateLimitPolicy with errors.ErrorApplicationLimit (429).
+// Every response carries `RateLimit-Limit`, `RateLimit-Remaining` and `RateLimit-Reset` headers,
+// and rejected one carries `Retry-After` too.
+type RateLimit struct {
+	store        RateLimitStore
+	policy       RateLimitPolicy
+	key          RateLimitKeyFunc
+	prefix       string
+	failOpen     bool
+	errorEncoder ErrorEncoder
+}
+
+// NewRateLimit returns RateLimit limiting by IP, example: NewRateLimit(NewMemoryRateLimitStore(), policy).
+// It panics on invalid policy (see RateLimitPolicy.Validate), so misconfiguration is found at startup.
+func NewRateLimit(store RateLimitStore, policy RateLimitPolicy, opts ...RateLimitOption) *RateLimit {
+	if err := policy.Validate(); err != nil {
+		panic(err)
+	}
+
+	if policy.Burst <= 0 {
+		policy.Burst = policy.Limit
+	}
+
+	rl := &RateLimit{
+		store:        store,
+		policy:       policy,
+		key:          KeyByIP,
+		failOpen:     true,
+		errorEncoder: defaultErrorEncoder(http.StatusTooManyRequests),
+	}
+
+	for _, opt := range opts {
+		opt(rl)
+	}
+
+	// keeps counters of different policies apart when they share the same store
+	if rl.prefix == "" {
+		rl.prefix = fmt.Sprintf("%s:%d:%s", policy.Algorithm, policy.Limit, policy.Window)
+	}
+
+	return rl
+}
+
+type RateLimitOption func(*RateLimit)
+
+func KeyRateLimitOption(key RateLimitKeyFunc) RateLimitOption {
+	return func(rl *RateLimit) {
+		rl.key = key
+	}
+}
+
+// PrefixRateLimitOption sets prefix of every key on store, by default it is derived from the policy.
+func PrefixRateLimitOption(prefix string) RateLimitOption {
+	return func(rl *RateLimit) {
+		rl.prefix = prefix
+	}
+}
+
+// FailOpenRateLimitOption decides whether request is allowed when store fails, default is true,
+// so unavailable store does not take the whole service down.
+func FailOpenRateLimitOption(failOpen bool) RateLimitOption {
+	return func(rl *RateLimit) {
+		rl.failOpen = failOpen
+	}
+}
+
+// ErrorEncoderRateLimitOption replaces ErrorEncoder carried by request ctx, see ContextWithErrorEncoder.
+func ErrorEncoderRateLimitOption(errorEncoder ErrorEncoder) RateLimitOption {
+	return func(rl *RateLimit) {
+		rl.errorEncoder = errorEncoder
+	}
+}
+
+func (rl *RateLimit) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := rl.key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := rl.store.Take(r.Context(), rl.prefix+":"+key, rl.policy)
+		if err != nil {
+			if rl.failOpen {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rl.errorEncoder(w, r, errors.ErrorApplicationLimit)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rl.policy.Limit, ceilSeconds(rl.policy.Window)))
+
+		if !result.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			rl.errorEncoder(w, r, errors.ErrorApplicationLimit)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ceilSeconds rounds d up, so client never retries too early.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// routePattern returns route pattern of r, when it is not routed yet, the route is looked up without serving it.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return r.URL.Path
+	}
+
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+
+	path := rctx.RoutePath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	tctx := chi.NewRouteContext()
+	if rctx.Routes != nil && rctx.Routes.Match(tctx, r.Method, path) {
+		return tctx.RoutePattern()
+	}
+
+	return r.URL.Path
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimitRejectsInvalidPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RateLimitPolicy
+	}{
+		{"zero limit", RateLimitPolicy{Limit: 0, Window: time.Second}},
+		{"negative limit", RateLimitPolicy{Limit: -1, Window: time.Second}},
+		{"zero window", RateLimitPolicy{Limit: 1}},
+		{"negative window", RateLimitPolicy{Algorithm: SlidingWindow, Limit: 1, Window: -time.Second}},
+		{"unknown algorithm", RateLimitPolicy{Algorithm: 9, Limit: 1, Window: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("want panic")
+				}
+			}()
+
+			NewRateLimit(NewMemoryRateLimitStore(), tt.policy)
+		})
+	}
+}
+
+func TestRateLimitByIPIgnoresForwardedHeaders(t *testing.T) {
+	rl := NewRateLimit(NewMemoryRateLimitStore(), RateLimitPolicy{Algorithm: SlidingWindow, Limit: 2, Window: time.Minute})
+	h := rl.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "192.0.2.1:" + strconv.Itoa(1000+i)
+		// rotating forwarded headers must not reset the quota
+		r.Header.Set("X-Forwarded-For", "10.0.0."+strconv.Itoa(i))
+		r.Header.Set("X-Real-IP", "10.0.1."+strconv.Itoa(i))
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+	}
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter <= 0 {
+		t.Fatalf("Retry-After = %q, want positive seconds", w.Header().Get("Retry-After"))
+	}
+}
+
+// fakeClock is injected as now of MemoryRateLimitStore.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestMemoryStore() (*MemoryRateLimitStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryRateLimitStore()
+	s.now = clock.now
+	s.lastSweep = clock.t
+
+	return s, clock
+}
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	s, clock := newTestMemoryStore()
+	policy := RateLimitPolicy{Algorithm: TokenBucket, Limit: 2, Window: time.Second, Burst: 4}
+
+	for i := 0; i < 4; i++ {
+		result, _ := s.Take(context.Background(), "k", policy)
+		if !result.Allowed || result.Remaining != 3-i || result.Limit != 4 {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining of 4", i, result, 3-i)
+		}
+	}
+
+	result, _ := s.Take(context.Background(), "k", policy)
+	if result.Allowed {
+		t.Fatalf("request over burst = %+v, want rejected", result)
+	}
+	// 2 tokens per second
+	if result.RetryAfter != 500*time.Millisecond || result.Reset != 2*time.Second {
+		t.Errorf("retry after = %s, reset = %s, want 500ms and 2s", result.RetryAfter, result.Reset)
+	}
+
+	clock.t = clock.t.Add(250 * time.Millisecond)
+	if result, _ := s.Take(context.Background(), "k", policy); result.Allowed {
+		t.Errorf("request after half token = %+v, want rejected", result)
+	}
+
+	clock.t = clock.t.Add(250 * time.Millisecond)
+	if result, _ := s.Take(context.Background(), "k", policy); !result.Allowed {
+		t.Errorf("request after refill = %+v, want allowed", result)
+	}
+
+	// bucket never holds more than its capacity
+	clock.t = clock.t.Add(time.Hour)
+	if result, _ := s.Take(context.Background(), "k", policy); result.Remaining != 3 {
+		t.Errorf("remaining after idle = %d, want 3", result.Remaining)
+	}
+}
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	s, clock := newTestMemoryStore()
+	policy := RateLimitPolicy{Algorithm: SlidingWindow, Limit: 4, Window: 10 * time.Second}
+
+	for i := 0; i < 4; i++ {
+		if result, _ := s.Take(context.Background(), "k", policy); !result.Allowed || result.Remaining != 3-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, 3-i)
+		}
+	}
+
+	result, _ := s.Take(context.Background(), "k", policy)
+	if result.Allowed || result.RetryAfter != 10*time.Second || result.Reset != 10*time.Second {
+		t.Fatalf("request over limit = %+v, want rejected until window ends", result)
+	}
+
+	// 3/4 of previous window still counts: 4 * 0.75 = 3, so one more is allowed
+	clock.t = clock.t.Add(12500 * time.Millisecond)
+	if result, _ := s.Take(context.Background(), "k", policy); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("request on next window = %+v, want allowed with 0 remaining", result)
+	}
+
+	result, _ = s.Take(context.Background(), "k", policy)
+	if result.Allowed {
+		t.Fatalf("request over estimate = %+v, want rejected", result)
+	}
+	// previous window must fade to 2 requests: (1 - 2/4) * 10s = 5s, 2.5s elapsed already
+	if result.RetryAfter != 2500*time.Millisecond {
+		t.Errorf("retry after = %s, want 2.5s", result.RetryAfter)
+	}
+
+	clock.t = clock.t.Add(2500 * time.Millisecond)
+	if result, _ := s.Take(context.Background(), "k", policy); !result.Allowed {
+		t.Errorf("request after fade = %+v, want allowed", result)
+	}
+
+	// previous window is long gone
+	clock.t = clock.t.Add(time.Minute)
+	if result, _ := s.Take(context.Background(), "k", policy); !result.Allowed || result.Remaining != 3 {
+		t.Errorf("request after idle = %+v, want allowed with 3 remaining", result)
+	}
+}
+
+func TestMemoryStoreSweepsExpiredCounters(t *testing.T) {
+	s, clock := newTestMemoryStore()
+	policy := RateLimitPolicy{Algorithm: SlidingWindow, Limit: 1, Window: time.Second}
+
+	s.Take(context.Background(), "a", policy)
+	s.Take(context.Background(), "b", policy)
+
+	clock.t = clock.t.Add(time.Minute)
+	s.Take(context.Background(), "c", policy)
+
+	if len(s.windows) != 1 {
+		t.Errorf("got %d windows, want only the new one", len(s.windows))
+	}
+}
@@ -0,0 +1,30 @@
+// Package redistest provides in-process Redis-compatible server, so ratelimitredis.Store can be used on tests
+// without running Redis.
+package redistest
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Server is in-process Redis-compatible server, it can be stopped with Close to simulate unreachable Redis,
+// and its clock can be moved with FastForward to expire keys.
+type Server = miniredis.Miniredis
+
+// NewClient starts Server and returns client connected to it.
+//
+// Example:
+//
+//	srv, client := redistest.NewClient()
+//	defer srv.Close()
+//	store := ratelimitredis.NewStore(client)
+func NewClient() (*Server, *redis.Client) {
+	srv := miniredis.NewMiniRedis()
+	if err := srv.Start(); err != nil {
+		// only happens when no port is available
+		panic(err)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	return srv, client
+}
@@ -0,0 +1,101 @@
+package ratelimitredis
+
+import "github.com/redis/go-redis/v9"
+
+// Both scripts use time of Redis instead of the caller, so clock of every replica does not matter.
+// Time is in milliseconds, and fractions are returned as string since Redis truncates Lua numbers into integer.
+
+// tokenBucketScript
+// KEYS[1]: key of the bucket
+// ARGV[1]: capacity
+// ARGV[2]: refill rate, tokens per millisecond
+// returns: allowed (0/1), tokens left, reset in ms, retry after in ms
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', key, 'tokens', 'last')
+local tokens = tonumber(state[1]) or capacity
+local last = tonumber(state[2]) or now
+
+tokens = math.min(capacity, tokens + math.max(0, now - last) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = (1 - tokens) / rate
+end
+
+local reset = (capacity - tokens) / rate
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'last', now)
+-- once it is full again, the bucket is the same as new one
+redis.call('PEXPIRE', key, math.ceil(reset) + 1000)
+
+return {allowed, tostring(tokens), tostring(reset), tostring(retry)}
+`)
+
+// slidingWindowScript
+// KEYS[1]: key of the window
+// ARGV[1]: limit
+// ARGV[2]: window in ms
+// returns: allowed (0/1), remaining, reset in ms, retry after in ms
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local start = now - (now % window)
+
+local state = redis.call('HMGET', key, 'start', 'current', 'previous')
+local wstart = tonumber(state[1]) or start
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+
+if wstart ~= start then
+	if wstart + window == start then
+		previous = current
+	else
+		-- previous window is long gone
+		previous = 0
+	end
+	current = 0
+end
+
+local elapsed = now - start
+local estimate = previous * (1 - elapsed / window) + current
+local reset = start + window - now
+
+local allowed = 0
+local remaining = 0
+local retry = 0
+if estimate + 1 <= limit then
+	current = current + 1
+	allowed = 1
+	remaining = limit - math.ceil(estimate + 1)
+else
+	retry = reset
+	-- previous window may fade out enough before the current one ends
+	local room = limit - 1 - current
+	if room >= 0 and previous > 0 then
+		local fade = (1 - room / previous) * window
+		if fade > elapsed then
+			retry = fade - elapsed
+		end
+	end
+end
+
+redis.call('HSET', key, 'start', start, 'current', current, 'previous', previous)
+redis.call('PEXPIRE', key, window * 2)
+
+return {allowed, remaining, tostring(reset), tostring(retry)}
+`)
@@ -0,0 +1,205 @@
+// Package ratelimitredis provides middleware.RateLimitStore backed by Redis, so rate limit is shared by every replica.
+// Any server speaking Redis protocol with Lua scripting can be used, example: Redis, KeyDB or Valkey.
+package ratelimitredis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dee-el/go-fw/transport/http/middleware"
+)
+
+// Store is middleware.RateLimitStore which counts requests atomically with Lua scripts on Redis.
+// When Redis is unreachable, requests are counted by fallback store instead, see WithFallback.
+type Store struct {
+	client   redis.Scripter
+	prefix   string
+	fallback middleware.RateLimitStore
+	// how long Redis is skipped after it fails, so every request does not wait for it to time out
+	retryInterval time.Duration
+	onError       func(err error)
+
+	mu          sync.Mutex
+	unreachable time.Time
+}
+
+type Option func(s *Store)
+
+// WithPrefix is an option to prefix every key on Redis, default is `ratelimit:`.
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithFallback is an option to replace fallback store, default is middleware.MemoryRateLimitStore.
+// Keep in mind limit of fallback is counted per replica, nil means error is returned instead,
+// then it is up to middleware.FailOpenRateLimitOption.
+func WithFallback(fallback middleware.RateLimitStore) Option {
+	return func(s *Store) {
+		s.fallback = fallback
+	}
+}
+
+// WithRetryInterval is an option to set how long Redis is skipped after it fails, default is 5 seconds.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.retryInterval = interval
+	}
+}
+
+// WithErrorHandler is an option to get notified when Redis fails, example: to log it.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(s *Store) {
+		s.onError = fn
+	}
+}
+
+// NewStore returns Store using client, example: redis.NewClient or redis.NewClusterClient.
+func NewStore(client redis.Scripter, opts ...Option) *Store {
+	s := &Store{
+		client:        client,
+		prefix:        "ratelimit:",
+		fallback:      middleware.NewMemoryRateLimitStore(),
+		retryInterval: 5 * time.Second,
+		onError:       func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Store) Take(ctx context.Context, key string, policy middleware.RateLimitPolicy) (middleware.RateLimitResult, error) {
+	// invalid policy says nothing about Redis health either
+	if err := policy.Validate(); err != nil {
+		return middleware.RateLimitResult{}, err
+	}
+
+	if policy.Window < time.Millisecond {
+		return middleware.RateLimitResult{}, fmt.Errorf("ratelimitredis: window must be at least 1ms, got %s", policy.Window)
+	}
+
+	if s.skipped() {
+		return s.takeFallback(ctx, key, policy, nil)
+	}
+
+	result, err := s.take(ctx, s.prefix+key, policy)
+	if err != nil {
+		// caller gave up, it says nothing about Redis health
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		s.onError(err)
+		s.markUnreachable()
+		return s.takeFallback(ctx, key, policy, err)
+	}
+
+	return result, nil
+}
+
+func (s *Store) take(ctx context.Context, key string, policy middleware.RateLimitPolicy) (middleware.RateLimitResult, error) {
+	window := policy.Window.Milliseconds()
+	if policy.Algorithm == middleware.SlidingWindow {
+		values, err := slidingWindowScript.Run(ctx, s.client, []string{key}, policy.Limit, window).Slice()
+		if err != nil {
+			return middleware.RateLimitResult{}, err
+		}
+
+		return parseResult(values, policy.Limit, func(v interface{}) (int, error) {
+			remaining, ok := v.(int64)
+			if !ok {
+				return 0, fmt.Errorf("ratelimitredis: unexpected remaining %v", v)
+			}
+			return int(remaining), nil
+		})
+	}
+
+	capacity := policy.Burst
+	if capacity <= 0 {
+		capacity = policy.Limit
+	}
+	rate := float64(policy.Limit) / float64(window)
+
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{key}, capacity, strconv.FormatFloat(rate, 'f', -1, 64)).Slice()
+	if err != nil {
+		return middleware.RateLimitResult{}, err
+	}
+
+	return parseResult(values, capacity, func(v interface{}) (int, error) {
+		tokens, err := parseFloat(v)
+		return int(tokens), err
+	})
+}
+
+// parseResult parses reply of both scripts: allowed, remaining, reset and retry after.
+func parseResult(values []interface{}, limit int, remaining func(v interface{}) (int, error)) (middleware.RateLimitResult, error) {
+	if len(values) != 4 {
+		return middleware.RateLimitResult{}, fmt.Errorf("ratelimitredis: unexpected reply %v", values)
+	}
+
+	result := middleware.RateLimitResult{Limit: limit}
+	allowed, _ := values[0].(int64)
+	result.Allowed = allowed == 1
+
+	var err error
+	if result.Remaining, err = remaining(values[1]); err != nil {
+		return result, err
+	}
+
+	reset, err := parseFloat(values[2])
+	if err != nil {
+		return result, err
+	}
+	result.Reset = time.Duration(reset * float64(time.Millisecond))
+
+	retry, err := parseFloat(values[3])
+	if err != nil {
+		return result, err
+	}
+	result.RetryAfter = time.Duration(retry * float64(time.Millisecond))
+
+	return result, nil
+}
+
+func parseFloat(v interface{}) (float64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("ratelimitredis: unexpected number %v", v)
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+func (s *Store) takeFallback(ctx context.Context, key string, policy middleware.RateLimitPolicy, cause error) (middleware.RateLimitResult, error) {
+	if s.fallback == nil {
+		if cause == nil {
+			cause = fmt.Errorf("ratelimitredis: redis is unreachable")
+		}
+		return middleware.RateLimitResult{}, cause
+	}
+
+	return s.fallback.Take(ctx, key, policy)
+}
+
+func (s *Store) skipped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.unreachable.IsZero() && time.Since(s.unreachable) < s.retryInterval
+}
+
+func (s *Store) markUnreachable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unreachable = time.Now()
+}
@@ -0,0 +1,201 @@
+package ratelimitredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dee-el/go-fw/transport/http/middleware"
+	"github.com/dee-el/go-fw/transport/http/middleware/ratelimitredis/redistest"
+)
+
+// start is aligned to every window used below, so sliding windows start exactly on it.
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func take(t *testing.T, s *Store, key string, policy middleware.RateLimitPolicy) middleware.RateLimitResult {
+	t.Helper()
+
+	result, err := s.Take(context.Background(), key, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestStoreTokenBucket(t *testing.T) {
+	srv, client := redistest.NewClient()
+	defer srv.Close()
+	srv.SetTime(start)
+
+	s := NewStore(client, WithFallback(nil))
+	policy := middleware.RateLimitPolicy{Algorithm: middleware.TokenBucket, Limit: 2, Window: time.Second}
+
+	for i := 0; i < 2; i++ {
+		if result := take(t, s, "k", policy); !result.Allowed || result.Remaining != 1-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, 1-i)
+		}
+	}
+
+	result := take(t, s, "k", policy)
+	if result.Allowed {
+		t.Fatalf("third request = %+v, want rejected", result)
+	}
+	// one token refills every 500ms
+	if result.RetryAfter != 500*time.Millisecond || result.Reset != time.Second {
+		t.Errorf("retry after = %s, reset = %s, want 500ms and 1s", result.RetryAfter, result.Reset)
+	}
+
+	srv.SetTime(start.Add(500 * time.Millisecond))
+	if result := take(t, s, "k", policy); !result.Allowed {
+		t.Errorf("request after refill = %+v, want allowed", result)
+	}
+
+	if !srv.Exists("ratelimit:k") {
+		t.Error("bucket is not stored on redis")
+	}
+}
+
+func TestStoreTokenBucketBurst(t *testing.T) {
+	srv, client := redistest.NewClient()
+	defer srv.Close()
+	srv.SetTime(start)
+
+	s := NewStore(client, WithFallback(nil))
+	policy := middleware.RateLimitPolicy{Algorithm: middleware.TokenBucket, Limit: 1, Window: time.Second, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if result := take(t, s, "k", policy); !result.Allowed || result.Limit != 3 {
+			t.Fatalf("request %d = %+v, want allowed with limit 3", i, result)
+		}
+	}
+
+	if result := take(t, s, "k", policy); result.Allowed {
+		t.Errorf("request over burst = %+v, want rejected", result)
+	}
+}
+
+func TestStoreSlidingWindow(t *testing.T) {
+	srv, client := redistest.NewClient()
+	defer srv.Close()
+	srv.SetTime(start)
+
+	s := NewStore(client, WithFallback(nil))
+	policy := middleware.RateLimitPolicy{Algorithm: middleware.SlidingWindow, Limit: 2, Window: 10 * time.Second}
+
+	for i := 0; i < 2; i++ {
+		if result := take(t, s, "k", policy); !result.Allowed || result.Remaining != 1-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, 1-i)
+		}
+	}
+
+	result := take(t, s, "k", policy)
+	if result.Allowed || result.RetryAfter != 10*time.Second {
+		t.Fatalf("third request = %+v, want rejected until window ends", result)
+	}
+
+	// half of the previous window still counts: 2 * 0.5 = 1, so one more is allowed
+	srv.SetTime(start.Add(15 * time.Second))
+	if result := take(t, s, "k", policy); !result.Allowed {
+		t.Fatalf("request on next window = %+v, want allowed", result)
+	}
+
+	result = take(t, s, "k", policy)
+	if result.Allowed {
+		t.Fatalf("request over estimate = %+v, want rejected", result)
+	}
+	// previous window never fades enough since current one is full already
+	if result.RetryAfter != 5*time.Second {
+		t.Errorf("retry after = %s, want 5s", result.RetryAfter)
+	}
+
+	// previous window is long gone
+	srv.SetTime(start.Add(time.Minute))
+	if result := take(t, s, "k", policy); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("request after idle = %+v, want allowed with 1 remaining", result)
+	}
+}
+
+func TestStoreFallsBackWhenRedisIsDown(t *testing.T) {
+	srv, client := redistest.NewClient()
+	srv.Close()
+
+	var errs int
+	s := NewStore(client, WithErrorHandler(func(err error) { errs++ }))
+	policy := middleware.RateLimitPolicy{Algorithm: middleware.SlidingWindow, Limit: 1, Window: time.Minute}
+
+	if result := take(t, s, "k", policy); !result.Allowed {
+		t.Fatalf("first request = %+v, want allowed by fallback", result)
+	}
+	if result := take(t, s, "k", policy); result.Allowed {
+		t.Errorf("second request = %+v, want rejected by fallback", result)
+	}
+	if errs != 1 {
+		t.Errorf("error handler called %d times, want 1", errs)
+	}
+}
+
+func TestStoreWithoutFallbackReturnsError(t *testing.T) {
+	srv, client := redistest.NewClient()
+	srv.Close()
+
+	s := NewStore(client, WithFallback(nil))
+	policy := middleware.RateLimitPolicy{Limit: 1, Window: time.Minute}
+
+	// once from Redis itself, then while it is skipped
+	for i := 0; i < 2; i++ {
+		if _, err := s.Take(context.Background(), "k", policy); err == nil {
+			t.Errorf("request %d: want error", i)
+		}
+	}
+}
+
+func TestStoreSkipsRedisWithinRetryInterval(t *testing.T) {
+	srv, client := redistest.NewClient()
+	defer srv.Close()
+	addr := srv.Addr()
+	srv.Close()
+
+	var errs int
+	s := NewStore(client, WithRetryInterval(time.Hour), WithErrorHandler(func(err error) { errs++ }))
+	policy := middleware.RateLimitPolicy{Limit: 10, Window: time.Minute}
+
+	take(t, s, "k", policy)
+	if err := srv.StartAddr(addr); err != nil {
+		t.Fatal(err)
+	}
+
+	take(t, s, "k", policy)
+	if errs != 1 || srv.Exists("ratelimit:k") {
+		t.Errorf("errors = %d, key on redis = %v, want Redis skipped after the first failure", errs, srv.Exists("ratelimit:k"))
+	}
+
+	// retry interval is over
+	s.retryInterval = 0
+	take(t, s, "k", policy)
+	if errs != 1 || !srv.Exists("ratelimit:k") {
+		t.Errorf("errors = %d, key on redis = %v, want Redis used again", errs, srv.Exists("ratelimit:k"))
+	}
+}
+
+func TestStoreRejectsInvalidPolicy(t *testing.T) {
+	srv, client := redistest.NewClient()
+	defer srv.Close()
+
+	var errs int
+	s := NewStore(client, WithErrorHandler(func(err error) { errs++ }))
+
+	for _, policy := range []middleware.RateLimitPolicy{
+		{Limit: 1},
+		{Limit: 1, Window: time.Microsecond},
+	} {
+		if _, err := s.Take(context.Background(), "k", policy); err == nil {
+			t.Errorf("policy %+v: want error", policy)
+		}
+	}
+
+	// Redis is not blamed for it
+	if errs != 0 || s.skipped() {
+		t.Errorf("errors = %d, skipped = %v, want Redis untouched", errs, s.skipped())
+	}
+}
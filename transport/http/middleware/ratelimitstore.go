@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimitStore is RateLimitStore keeping counters in memory, so they are not shared between instances.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	windows   map[string]*slidingWindow
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	expireAt time.Time
+}
+
+type slidingWindow struct {
+	start    time.Time
+	current  int
+	previous int
+	expireAt time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   map[string]*tokenBucket{},
+		windows:   map[string]*slidingWindow{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	if err := policy.Validate(); err != nil {
+		return RateLimitResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now, policy.Window)
+
+	if policy.Algorithm == SlidingWindow {
+		return s.takeSlidingWindow(now, key, policy), nil
+	}
+
+	return s.takeTokenBucket(now, key, policy), nil
+}
+
+func (s *MemoryRateLimitStore) takeTokenBucket(now time.Time, key string, policy RateLimitPolicy) RateLimitResult {
+	capacity := float64(policy.Burst)
+	if capacity <= 0 {
+		capacity = float64(policy.Limit)
+	}
+	// tokens per second
+	rate := float64(policy.Limit) / policy.Window.Seconds()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{Limit: int(capacity)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((capacity - b.tokens) / rate)
+	// once it is full again, the bucket is the same as new one
+	b.expireAt = now.Add(result.Reset)
+
+	return result
+}
+
+func (s *MemoryRateLimitStore) takeSlidingWindow(now time.Time, key string, policy RateLimitPolicy) RateLimitResult {
+	start := now.Truncate(policy.Window)
+
+	w, ok := s.windows[key]
+	if !ok {
+		w = &slidingWindow{start: start}
+		s.windows[key] = w
+	}
+
+	switch {
+	case w.start.Equal(start):
+	case w.start.Add(policy.Window).Equal(start):
+		w.previous, w.current = w.current, 0
+		w.start = start
+	default:
+		// previous window is long gone
+		w.previous, w.current = 0, 0
+		w.start = start
+	}
+	w.expireAt = start.Add(2 * policy.Window)
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(policy.Window)
+	estimate := float64(w.previous)*weight + float64(w.current)
+
+	result := RateLimitResult{Limit: policy.Limit, Reset: start.Add(policy.Window).Sub(now)}
+	if estimate+1 <= float64(policy.Limit) {
+		w.current++
+		result.Allowed = true
+		result.Remaining = policy.Limit - int(math.Ceil(estimate+1))
+		return result
+	}
+
+	result.RetryAfter = result.Reset
+	// previous window may fade out enough before the current one ends
+	if room := float64(policy.Limit - 1 - w.current); room >= 0 && w.previous > 0 {
+		fade := time.Duration((1 - room/float64(w.previous)) * float64(policy.Window))
+		if fade > elapsed {
+			result.RetryAfter = fade - elapsed
+		}
+	}
+
+	return result
+}
+
+// sweep removes expired counters once every window at most, so memory does not grow with every key ever seen.
+func (s *MemoryRateLimitStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.After(b.expireAt) {
+			delete(s.buckets, key)
+		}
+	}
+
+	for key, w := range s.windows {
+		if now.After(w.expireAt) {
+			delete(s.windows, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
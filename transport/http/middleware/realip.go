@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP rewrites RemoteAddr to client IP told by `X-Forwarded-For` or `X-Real-IP` header,
+// but only when the request comes from trusted proxy, since any client can send those headers.
+// So IP based access, example: BypassIPsMaintenanceOption and KeyByIP, can not be dodged by spoofed headers.
+type RealIP struct {
+	trusted []*net.IPNet
+}
+
+// NewRealIP returns RealIP trusting proxies of given IPs or CIDRs, example: `10.0.0.0/8` of the load balancer.
+// Without any of them, RemoteAddr is never rewritten. It panics on invalid IP, so misconfiguration is found at startup.
+func NewRealIP(trustedProxies ...string) *RealIP {
+	trusted, err := parseIPNets(trustedProxies)
+	if err != nil {
+		panic(err)
+	}
+
+	return &RealIP{trusted: trusted}
+}
+
+func (rip *RealIP) Handler(next http.Handler) http.Handler {
+	if len(rip.trusted) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := remoteIP(r); ip != nil && containsIP(rip.trusted, ip) {
+			if client := rip.clientIP(r); client != "" {
+				r.RemoteAddr = client
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the nearest address of `X-Forwarded-For` which is not trusted proxy, as the ones before it
+// can be sent by client. `X-Real-IP` is only used when there is no `X-Forwarded-For`.
+func (rip *RealIP) clientIP(r *http.Request) string {
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+
+	var client string
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+
+		client = ip.String()
+		if !containsIP(rip.trusted, ip) {
+			return client
+		}
+	}
+
+	if client != "" {
+		return client
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+
+	return ""
+}
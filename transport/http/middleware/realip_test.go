@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{"no trusted proxy", nil, "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"10.1.2.3"}}, "192.0.2.1:1234"},
+		{"untrusted peer", []string{"10.0.0.0/8"}, "192.0.2.1:1234", http.Header{"X-Real-Ip": {"10.1.2.3"}}, "192.0.2.1:1234"},
+		{"trusted peer", []string{"10.0.0.0/8"}, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"nearest untrusted address", []string{"10.0.0.0/8"}, "10.0.0.1:1234",
+			http.Header{"X-Forwarded-For": {"10.9.9.9, 198.51.100.7, 10.0.0.2"}}, "198.51.100.7"},
+		{"multiple headers", []string{"10.0.0.0/8"}, "10.0.0.1:1234",
+			http.Header{"X-Forwarded-For": {"203.0.113.5", "198.51.100.7"}}, "198.51.100.7"},
+		{"only trusted addresses", []string{"10.0.0.0/8"}, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"X-Real-IP of trusted peer", []string{"10.0.0.1"}, "10.0.0.1:1234", http.Header{"X-Real-Ip": {"198.51.100.7"}}, "198.51.100.7"},
+		{"invalid header", []string{"10.0.0.0/8"}, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"unknown"}}, "10.0.0.1:1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := NewRealIP(tt.trusted...).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header = tt.header
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRealIPRejectsInvalidProxy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("want panic")
+		}
+	}()
+
+	NewRealIP("10.0.0.0/33")
+}
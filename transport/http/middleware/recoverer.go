@@ -1,30 +1,195 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
+	chi "github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+
 	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/metrics"
+	"github.com/dee-el/go-fw/tracederr"
 	"github.com/dee-el/go-fw/transport/http/response"
 )
 
-func Recoverer(next http.Handler) http.Handler {
+// MetricPanicsTotal is name of counter incremented on every recovered panic, labeled by `path` (route pattern,
+// or UnmatchedRoute) and `method`.
+const MetricPanicsTotal = "http_server_panics_total"
+
+// ErrorEncoder writes err as response, same as ErrorEncoder of transport/http, but receives the request
+// since there is no Handler in between to carry it.
+type ErrorEncoder func(w http.ResponseWriter, r *http.Request, err error)
+
+// PanicReporter is notified of every recovered panic, example: to send it to error tracking service.
+// err is tracederr.Error holding the stack frames where the panic happened.
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, r *http.Request, err *tracederr.Error)
+}
+
+// PanicReporterFunc is adapter to use ordinary function as PanicReporter.
+type PanicReporterFunc func(ctx context.Context, r *http.Request, err *tracederr.Error)
+
+func (f PanicReporterFunc) ReportPanic(ctx context.Context, r *http.Request, err *tracederr.Error) {
+	f(ctx, r, err)
+}
+
+// Recovery recovers panic into 500 response with errors.ErrorInternalServer.
+// Before the response is written, the panic is logged, reported, counted and recorded on the active tracing span.
+type Recovery struct {
+	logger       *zap.Logger
+	reporters    []PanicReporter
+	counter      metrics.CounterRecorder
+	errorEncoder ErrorEncoder
+}
+
+func NewRecovery(opts ...RecoveryOption) *Recovery {
+	rc := &Recovery{
+		logger:       zap.NewNop(),
+		errorEncoder: defaultErrorEncoder(http.StatusInternalServerError),
+	}
+
+	for _, opt := range opts {
+		opt(rc)
+	}
+
+	return rc
+}
+
+type RecoveryOption func(*Recovery)
+
+func LoggerRecoveryOption(logger *zap.Logger) RecoveryOption {
+	return func(rc *Recovery) {
+		rc.logger = logger
+	}
+}
+
+// ReportersRecoveryOption adds reporters, they are called in the given order.
+func ReportersRecoveryOption(reporters ...PanicReporter) RecoveryOption {
+	return func(rc *Recovery) {
+		rc.reporters = append(rc.reporters, reporters...)
+	}
+}
+
+// CounterRecoveryOption is an option to count panics as MetricPanicsTotal, example: metrics.MemoryRecorder.
+func CounterRecoveryOption(counter metrics.CounterRecorder) RecoveryOption {
+	return func(rc *Recovery) {
+		rc.counter = counter
+	}
+}
+
+func ErrorEncoderRecoveryOption(errorEncoder ErrorEncoder) RecoveryOption {
+	return func(rc *Recovery) {
+		rc.errorEncoder = errorEncoder
+	}
+}
+
+func (rc *Recovery) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
+			// http.ErrAbortHandler is meant to abort the response, let net/http handle it
 			if rvr := recover(); rvr != nil && rvr != http.ErrAbortHandler {
-				// logging what make panic happens
-				//log.New().Panic(rvr, debug.Stack())
-
-				// rewrite the response
-				resp := response.NewResponse(nil, errors.ErrorInternalServer)
-
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusInternalServerError)
-				// no need check err encoder
-				json.NewEncoder(w).Encode(resp)
+				rc.recover(w, r, rvr)
 			}
 		}()
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+func (rc *Recovery) recover(w http.ResponseWriter, r *http.Request, rvr interface{}) {
+	ctx := r.Context()
+
+	var cause error
+	if e, ok := rvr.(error); ok {
+		cause = fmt.Errorf("panic: %w", e)
+	} else {
+		cause = fmt.Errorf("panic: %v", rvr)
+	}
+	// skip this function and the deferred one, so frames start from the panicking function,
+	// runtime frames are skipped by tracederr
+	err := tracederr.NewTracedError(cause, 3, 0)
+
+	// since using chi as template mux http, utilize chi context to get regexed pattern path
+	// metrics label never uses URL path, see UnmatchedRoute
+	path, route := r.URL.Path, UnmatchedRoute
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		path = rctx.RoutePattern()
+		route = path
+	}
+
+	rc.logger.Error(
+		"HTTP panic",
+		zap.String("request_path", path),
+		zap.String("request_method", r.Method),
+		zap.String("request_id", chi_middleware.GetReqID(ctx)),
+		zap.String("trace_id", TraceIDFromContext(ctx)),
+		zap.Any("stack_errors", tracederr.PrintErrors(err, nil)),
+	)
+
+	for _, reporter := range rc.reporters {
+		reporter.ReportPanic(ctx, r, err)
+	}
+
+	if rc.counter != nil {
+		rc.counter.Add(MetricPanicsTotal, 1, metrics.Labels{"path": route, "method": r.Method})
+	}
+
+	TagSpanError(ctx, err)
+	RecordOTelError(ctx, err, http.StatusInternalServerError)
+
+	rc.errorEncoder(w, r, errors.ErrorInternalServer)
+}
+
+var defaultRecovery = NewRecovery()
+
+// Recoverer recovers panic with default Recovery, it writes JSON response and logs nothing.
+// Use NewRecovery to configure it.
+func Recoverer(next http.Handler) http.Handler {
+	return defaultRecovery.Handler(next)
+}
+
+type errorEncoderCtxKey struct{}
+
+// ContextWithErrorEncoder returns copy of ctx carrying errorEncoder, used by middleware which has no ErrorEncoder given.
+// Server of transport/http puts its own, so middleware errors are written by its ErrorEncoder and Dictionary.
+func ContextWithErrorEncoder(ctx context.Context, errorEncoder ErrorEncoder) context.Context {
+	return context.WithValue(ctx, errorEncoderCtxKey{}, errorEncoder)
+}
+
+// defaultErrorEncoder is used when middleware has no ErrorEncoder given, it uses ErrorEncoder carried by request ctx,
+// or writes err as JSON with status when there is none.
+func defaultErrorEncoder(status int) ErrorEncoder {
+	fallback := jsonErrorEncoder(status)
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		if enc, ok := r.Context().Value(errorEncoderCtxKey{}).(ErrorEncoder); ok && enc != nil {
+			enc(w, r, err)
+			return
+		}
+
+		fallback(w, r, err)
+	}
+}
+
+// jsonErrorEncoder writes err with status, unless err is internal error which is always written with 500.
+func jsonErrorEncoder(status int) ErrorEncoder {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		code := status
+		e, ok := err.(*errors.Error)
+		if !ok {
+			e = errors.ErrorInternalServer
+		}
+		if e.Type == errors.TypeInternalServerError {
+			code = http.StatusInternalServerError
+		}
+		resp := response.NewResponse(nil, e)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		// no need check err encoder
+		json.NewEncoder(w).Encode(resp)
+	}
+}
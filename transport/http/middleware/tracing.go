@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -8,6 +10,10 @@ import (
 	chi_middleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/tracederr"
 )
 
 type Tracing struct {
@@ -56,7 +62,51 @@ func (t *Tracing) Handler(next http.Handler) http.Handler {
 			span.SetTag(string(ext.HTTPStatusCode), rw.Status())
 			span.SetTag("http.headers", getHeaders(r.Header))
 			span.SetTag("http.request_ip", getIP(r))
+			if rw.Status() >= http.StatusInternalServerError {
+				ext.Error.Set(span, true)
+			}
 			span.Finish()
 		}()
 	})
 }
+
+// TagSpanError marks the active OpenTracing span of ctx as failed, it does nothing when there is no span.
+// errors.Error is tagged as `error.kind=business` along with `error.type` and `error.code`,
+// so traces can be searched by error code. Others are tagged as `error.kind=internal`.
+// Stack traces of tracederr.Error are logged too.
+func TagSpanError(ctx context.Context, err error) {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+
+	ext.Error.Set(span, true)
+
+	fields := []log.Field{
+		log.String("event", "error"),
+		log.String("message", err.Error()),
+	}
+
+	if e, ok := err.(*errors.Error); ok {
+		span.SetTag("error.kind", "business")
+		span.SetTag("error.type", string(e.Type))
+		span.SetTag("error.code", int(e.Code))
+
+		fields = append(fields,
+			log.String("error.kind", "business"),
+			log.String("error.type", string(e.Type)),
+			log.Int("error.code", int(e.Code)),
+		)
+		span.LogFields(fields...)
+		return
+	}
+
+	span.SetTag("error.kind", "internal")
+	fields = append(fields, log.String("error.kind", "internal"))
+
+	// every wrapped error along with its stack frames
+	stack, _ := json.Marshal(tracederr.PrintErrors(err, nil))
+	fields = append(fields, log.String("stack", string(stack)))
+
+	span.LogFields(fields...)
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/tracederr"
+)
+
+func TestTagSpanError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		// nil value means the tag must not be set
+		wantTags  map[string]interface{}
+		wantStack bool
+	}{
+		{"business", errors.ErrorNotFound, map[string]interface{}{
+			"error":      true,
+			"error.kind": "business",
+			"error.type": string(errors.ErrorNotFound.Type),
+			"error.code": int(errors.ErrorNotFound.Code),
+		}, false},
+		{"internal", tracederr.New("db down"), map[string]interface{}{
+			"error":      true,
+			"error.kind": "internal",
+			"error.code": nil,
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := mocktracer.New()
+			span := tracer.StartSpan("op")
+			TagSpanError(opentracing.ContextWithSpan(context.Background(), span), tt.err)
+			span.Finish()
+
+			mock := tracer.FinishedSpans()[0]
+			tags := mock.Tags()
+			for key, value := range tt.wantTags {
+				if tags[key] != value {
+					t.Errorf("tag %s = %v, want %v", key, tags[key], value)
+				}
+			}
+
+			logs := mock.Logs()
+			if len(logs) != 1 {
+				t.Fatalf("got %d logs, want 1", len(logs))
+			}
+
+			fields := map[string]string{}
+			for _, f := range logs[0].Fields {
+				fields[f.Key] = f.ValueString
+			}
+			if fields["event"] != "error" || fields["message"] != tt.err.Error() {
+				t.Errorf("log fields = %v, want the error", fields)
+			}
+
+			stack, ok := fields["stack"]
+			if ok != tt.wantStack {
+				t.Fatalf("logged stack = %v, want %v", ok, tt.wantStack)
+			}
+			// frames start from where tracederr was created
+			if ok && !strings.Contains(stack, "TestTagSpanError") {
+				t.Errorf("stack = %s, want frame of the test", stack)
+			}
+		})
+	}
+}
+
+func TestTagSpanErrorWithoutSpan(t *testing.T) {
+	// nothing to tag, it must not panic
+	TagSpanError(context.Background(), errors.ErrorNotFound)
+}
@@ -1,9 +1,15 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"reflect"
 	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func getHeaders(h http.Header) string {
@@ -28,3 +34,45 @@ func getIP(r *http.Request) string {
 
 	return strings.Split(r.RemoteAddr, ":")[0]
 }
+
+// remoteIP returns IP of r.RemoteAddr, it is rewritten to client IP by RealIP middleware when behind trusted proxy.
+// Unlike getIP, it never reads forwarded headers sent by client, so use it to grant or limit access.
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// RealIP sets RemoteAddr without port
+		host = r.RemoteAddr
+	}
+
+	return net.ParseIP(host)
+}
+
+// clientIP returns remoteIP as string, or RemoteAddr as is when it is not an IP, example: unix socket.
+func clientIP(r *http.Request) string {
+	if ip := remoteIP(r); ip != nil {
+		return ip.String()
+	}
+
+	return r.RemoteAddr
+}
+
+// TraceIDFromContext returns trace ID of the active span of ctx, either from OTelTracing or Tracing.
+// For OpenTracing, span context must have `TraceID()` method (example: Jaeger, Zipkin), otherwise it returns empty.
+func TraceIDFromContext(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+
+	// OpenTracing does not standardize trace ID, so look for the method commonly implemented by tracers
+	method := reflect.ValueOf(span.Context()).MethodByName("TraceID")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+
+	return fmt.Sprint(method.Call(nil)[0].Interface())
+}
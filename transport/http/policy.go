@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	chi "github.com/go-chi/chi/v5"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/transport/http/middleware"
+)
+
+// Policy declares who may call a route, principal must satisfy every part of it.
+// Principal is read from request context by PrincipalExtractor, so Authentication middleware must run before.
+//
+// Example:
+//
+//	s.Post("/orders", NewHandler(createOrder, decodeOrder, WithPolicy(Policy{Scopes: []string{"orders:write"}})))
+type Policy struct {
+	// Roles are allowed roles, principal must have at least one of them.
+	Roles []string
+	// Scopes are required permissions, principal must have all of them.
+	Scopes []string
+	// Checks are custom predicates, principal must pass all of them.
+	Checks []PolicyCheck
+}
+
+// PolicyCheck is named predicate, the name is used as missing permission and on audit.
+type PolicyCheck struct {
+	Name  string
+	Allow func(ctx context.Context, principal *middleware.Principal) bool
+}
+
+// RequireRoles returns Policy allowing principal with any of roles.
+func RequireRoles(roles ...string) Policy {
+	return Policy{Roles: roles}
+}
+
+// RequireScopes returns Policy allowing principal with all of scopes.
+func RequireScopes(scopes ...string) Policy {
+	return Policy{Scopes: scopes}
+}
+
+// RequireFunc returns Policy allowing principal passing allow, example: owner of the resource.
+func RequireFunc(name string, allow func(ctx context.Context, principal *middleware.Principal) bool) Policy {
+	return Policy{Checks: []PolicyCheck{{Name: name, Allow: allow}}}
+}
+
+// String describes policy for audit, example: `roles=admin|ops scopes=orders:write checks=owner`.
+func (p Policy) String() string {
+	var parts []string
+	if len(p.Roles) > 0 {
+		parts = append(parts, "roles="+strings.Join(p.Roles, "|"))
+	}
+
+	if len(p.Scopes) > 0 {
+		parts = append(parts, "scopes="+strings.Join(p.Scopes, ","))
+	}
+
+	if len(p.Checks) > 0 {
+		names := make([]string, len(p.Checks))
+		for i, c := range p.Checks {
+			names[i] = c.Name
+		}
+		parts = append(parts, "checks="+strings.Join(names, ","))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// missing returns the first permission principal does not have, empty when it is allowed.
+func (p Policy) missing(ctx context.Context, principal *middleware.Principal) string {
+	if len(p.Roles) > 0 && !containsAny(principal.Roles, p.Roles) {
+		return "role:" + strings.Join(p.Roles, "|")
+	}
+
+	for _, scope := range p.Scopes {
+		if !containsAny(principal.Permissions, []string{scope}) {
+			return "scope:" + scope
+		}
+	}
+
+	for _, c := range p.Checks {
+		if !c.Allow(ctx, principal) {
+			return "check:" + c.Name
+		}
+	}
+
+	return ""
+}
+
+func containsAny(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// PrincipalExtractor reads principal from ctx, see WithPrincipalExtractor.
+type PrincipalExtractor func(ctx context.Context) (*middleware.Principal, bool)
+
+// WithPolicy is an option to require policy on Handler, it is checked before the request is decoded.
+// Policies of the Server (see WithRoutePolicy) are checked too.
+func WithPolicy(p Policy) HandlerOption {
+	return func(h *Handler) {
+		h.policy = &p
+	}
+}
+
+// guardedHandler checks policies before serving next.
+type guardedHandler struct {
+	next         http.Handler
+	policies     []Policy
+	extractor    PrincipalExtractor
+	errorEncoder middleware.ErrorEncoder
+}
+
+func (g *guardedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	principal, ok := g.extractor(ctx)
+	if !ok || principal == nil {
+		g.errorEncoder(w, r, errors.ErrorAuthentication)
+		return
+	}
+
+	for _, p := range g.policies {
+		if missing := p.missing(ctx, principal); missing != "" {
+			err := errors.ErrorForbidden.Copy()
+			err.AddField("permission", missing)
+
+			recordSpanError(ctx, err)
+			g.errorEncoder(w, r, err)
+			return
+		}
+	}
+
+	g.next.ServeHTTP(w, r)
+}
+
+// RouteInfo describes registered route for audit.
+type RouteInfo struct {
+	Method string
+	Path   string
+	// Policies are every policy required by the route, empty means anyone may call it.
+	Policies []Policy
+}
+
+// Routes lists every registered route, including mounted ones, along with their policies.
+// Example: log them on startup, or expose them on an internal endpoint to review who may call what.
+func (s *Server) Routes() []RouteInfo {
+	var routes []RouteInfo
+	chi.Walk(s.mux, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		info := RouteInfo{Method: method, Path: route}
+		for _, m := range s.mounts {
+			if hasPathPrefix(route, m.path) {
+				info.Policies = append(info.Policies, m.policies...)
+			}
+		}
+
+		if g, ok := handler.(*guardedHandler); ok {
+			info.Policies = append(info.Policies, g.policies...)
+		}
+
+		routes = append(routes, info)
+		return nil
+	})
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// mountedPolicies are policies checked on every route of server attached by Mount.
+type mountedPolicies struct {
+	path     string
+	policies []Policy
+}
+
+// guard wraps hn with policies of the server and policy, it returns hn as is when there is none.
+func (s *Server) guard(hn http.Handler, policy *Policy, errorEncoder middleware.ErrorEncoder) http.Handler {
+	policies := append([]Policy{}, s.policies...)
+	if policy != nil {
+		policies = append(policies, *policy)
+	}
+
+	if len(policies) == 0 {
+		return hn
+	}
+
+	return &guardedHandler{
+		next:         hn,
+		policies:     policies,
+		extractor:    s.principalExtractor,
+		errorEncoder: errorEncoder,
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dee-el/go-fw/transport/http/middleware"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+func okHandler(opts ...HandlerOption) *Handler {
+	return NewHandler(func(ctx context.Context, request *Request) (response.Response, int, error) {
+		return *response.NewResponse("ok", nil), http.StatusOK, nil
+	}, nopRequestDecoder, opts...)
+}
+
+// principalFromHeader authenticates `X-Role` header as role of the principal.
+func principalFromHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if role := r.Header.Get("X-Role"); role != "" {
+			ctx := middleware.ContextWithPrincipal(r.Context(), &middleware.Principal{ID: "u1", Roles: []string{role}})
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	s := NewServer()
+	s.Middleware(principalFromHeader)
+	s.Get("/public", okHandler())
+	s.Get("/scoped", okHandler(WithPolicy(RequireScopes("orders:write"))))
+
+	s.Route("/route", func(sub *Server) {
+		sub.RequirePolicy(RequireRoles("admin"))
+		sub.Get("/x", okHandler())
+	})
+
+	mounted := NewServer(WithToggleBasicMiddleware(false))
+	mounted.Get("/x", okHandler())
+	s.RequirePolicy(RequireRoles("ops"))
+	s.Mount("/mounted", mounted)
+
+	tests := []struct {
+		path string
+		role string
+		want int
+	}{
+		{"/public", "", http.StatusOK},
+		{"/scoped", "", http.StatusUnauthorized},
+		{"/scoped", "admin", http.StatusForbidden},
+		{"/route/x", "", http.StatusUnauthorized},
+		{"/route/x", "member", http.StatusForbidden},
+		{"/route/x", "admin", http.StatusOK},
+		{"/mounted/x", "", http.StatusUnauthorized},
+		{"/mounted/x", "member", http.StatusForbidden},
+		{"/mounted/x", "ops", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.role, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("X-Role", tt.role)
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	policies := map[string]string{}
+	for _, route := range s.Routes() {
+		var desc string
+		for i, p := range route.Policies {
+			if i > 0 {
+				desc += "; "
+			}
+			desc += p.String()
+		}
+		policies[route.Path] = desc
+	}
+
+	want := map[string]string{
+		"/public":    "",
+		"/scoped":    "scopes=orders:write",
+		"/route/x":   "roles=admin",
+		"/mounted/x": "roles=ops",
+	}
+	for path, desc := range want {
+		if got, ok := policies[path]; !ok || got != desc {
+			t.Errorf("policies of %s = %q (listed %v), want %q", path, got, ok, desc)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// ProblemDetails is error body as described on RFC 7807, sent as `application/problem+json`.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extensions are additional members, flattened on the same level of the standard members.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	// standard members can not be overwritten by extensions
+	m["type"] = p.Type
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+type problemEncoder struct {
+	typeBaseURI string
+	extensions  func(ctx context.Context, err *errors.Error) map[string]interface{}
+}
+
+type ProblemOption func(p *problemEncoder)
+
+// WithProblemTypeBaseURI sets base URI of `type` member, the errors.Type will be appended to it.
+// Example: base `https://example.com/problems/` makes `https://example.com/problems/NotFoundError`.
+// Without this option, `type` will be `about:blank`.
+func WithProblemTypeBaseURI(base string) ProblemOption {
+	return func(p *problemEncoder) {
+		p.typeBaseURI = base
+	}
+}
+
+// WithProblemExtensions adds more extension members besides `code`, `error_type` and `fields`.
+func WithProblemExtensions(fn func(ctx context.Context, err *errors.Error) map[string]interface{}) ProblemOption {
+	return func(p *problemEncoder) {
+		p.extensions = fn
+	}
+}
+
+// NewProblemErrorEncoder returns ErrorEncoder which sends err in `application/problem+json` (RFC 7807).
+// errors.Error is mapped as:
+//   - Type: `type` URI (see WithProblemTypeBaseURI) and `error_type` extension
+//   - Code: `code` extension
+//   - Message: `detail`
+//   - Fields: `fields` extension
+//
+// While `status` is taken from Dictionary, `title` is its HTTP status text, and `instance` is the request path.
+func NewProblemErrorEncoder(opts ...ProblemOption) ErrorEncoder {
+	p := &problemEncoder{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p.encode
+}
+
+// ProblemJSONErrorEncoder is ErrorEncoder in `application/problem+json` format with default options.
+// Use WithErrorEncoder to select it on Handler, or WithDefaultErrorEncoder for whole Server.
+var ProblemJSONErrorEncoder = NewProblemErrorEncoder()
+
+func (p *problemEncoder) encode(ctx context.Context, w http.ResponseWriter, err error) {
+	resp, httpStatus := errorResponse(err)
+	e := resp.Error
+
+	problem := ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(httpStatus),
+		Status: httpStatus,
+		Detail: e.Message,
+		Extensions: map[string]interface{}{
+			"code":       e.Code,
+			"error_type": e.Type,
+		},
+	}
+
+	if p.typeBaseURI != "" {
+		problem.Type = strings.TrimSuffix(p.typeBaseURI, "/") + "/" + string(e.Type)
+	}
+
+	if r, ok := RequestFromContext(ctx); ok {
+		problem.Instance = r.URL.Path
+	}
+
+	if len(e.Fields) > 0 {
+		problem.Extensions["fields"] = e.Fields
+	}
+
+	if p.extensions != nil {
+		for k, v := range p.extensions(ctx, e) {
+			problem.Extensions[k] = v
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(httpStatus)
+	// no need check err encoder
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dee-el/go-fw/validation"
+)
+
+// DefaultTagName is struct tag to set default value of querystring / form field when it is not sent.
+// For slice, values are separated by comma.
+//
+// Example:
+//
+//	type ListUsers struct {
+//		Statuses []string  `form:"status" default:"active,pending"`
+//		Since    time.Time `form:"since"`
+//		Sort     string    `form:"sort" default:"-created_at"`
+//		Page     int       `form:"page" default:"1" validate:"min=1"`
+//		Limit    int       `form:"limit" default:"20" validate:"min=1,max=100"`
+//	}
+const DefaultTagName = "default"
+
+// time layouts accepted by form decoder, tried in order
+var timeLayouts = []string{time.RFC3339Nano, time.RFC3339, "2006-01-02"}
+
+func init() {
+	formDecoder.RegisterCustomTypeFunc(func(vals []string) (interface{}, error) {
+		if vals[0] == "" {
+			return time.Time{}, nil
+		}
+
+		var t time.Time
+		var err error
+		for _, layout := range timeLayouts {
+			t, err = time.Parse(layout, vals[0])
+			if err == nil {
+				return t, nil
+			}
+		}
+
+		return nil, err
+	}, time.Time{})
+}
+
+// QueryParser decode querystring to object payload, then validate it using `validate` tags.
+// Keys are taken from `form` tag, or field name when the tag is not set.
+// Field which is not sent will be filled by its `default` tag.
+func QueryParser(r *http.Request, payload interface{}) error {
+	err := decodeQuery(r.URL.Query(), payload)
+	if err != nil {
+		return err
+	}
+
+	return validation.Validate(payload)
+}
+
+func decodeQuery(values url.Values, payload interface{}) error {
+	return formDecoder.Decode(payload, withDefaults(values, payload))
+}
+
+// withDefaults returns copy of values added by `default` tag of payload fields which are not sent.
+func withDefaults(values url.Values, payload interface{}) url.Values {
+	t := reflect.TypeOf(payload)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return values
+	}
+
+	merged := url.Values{}
+	for k, v := range values {
+		merged[k] = v
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		def, ok := f.Tag.Lookup(DefaultTagName)
+		if !ok || !f.IsExported() {
+			continue
+		}
+
+		key, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+
+		if _, sent := merged[key]; sent {
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Slice {
+			merged[key] = strings.Split(def, ",")
+			continue
+		}
+
+		merged[key] = []string{def}
+	}
+
+	return merged
+}
+
+// formTagged returns values of keys named by explicit `form` tag of payload fields only,
+// so fields meant for body, example: `json:"is_admin"`, can not be set from querystring by their field name.
+func formTagged(values url.Values, payload interface{}) url.Values {
+	t := reflect.TypeOf(payload)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	filtered := url.Values{}
+	if t == nil || t.Kind() != reflect.Struct {
+		return filtered
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if !f.IsExported() || key == "" || key == "-" {
+			continue
+		}
+
+		for k, v := range values {
+			// nested struct, map and indexed slice, example: `filter.status` and `ids[0]`
+			if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(k, key+"[") {
+				filtered[k] = v
+			}
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type listUsers struct {
+	Page  int `form:"page" default:"1" validate:"min=1"`
+	Limit int `form:"limit" default:"20" validate:"min=1,max=100"`
+}
+
+func TestQueryParser(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantErr   bool
+		wantPage  int
+		wantLimit int
+	}{
+		{"", false, 1, 20},
+		{"?page=3&limit=50", false, 3, 50},
+		{"?page=0", true, 0, 0},
+		{"?limit=0", true, 0, 0},
+		{"?limit=101", true, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var payload listUsers
+			err := QueryParser(httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil), &payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want err, got %+v", payload)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if payload.Page != tt.wantPage || payload.Limit != tt.wantLimit {
+				t.Fatalf("payload = %+v, want page %d limit %d", payload, tt.wantPage, tt.wantLimit)
+			}
+		})
+	}
+}
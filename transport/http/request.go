@@ -2,17 +2,31 @@ package http
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/go-playground/form/v4"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/validation"
 )
 
-// Request is extracted request object. For querystring, user should handle it manually.
+// Request is extracted request object.
 type Request struct {
 	Payload interface{}
 	// let it empty will be let handler retrieve it for user automatically
 	URLParams URLParams
+	// let it empty will be let handler retrieve it for user automatically.
+	// To decode it into struct, use QueryParser.
+	Query url.Values
 }
 
 // this is from URL slugs, but does not rule out manual processing from RequestDecoder.
@@ -30,27 +44,226 @@ func (up URLParams) Get(key string) string {
 
 var formDecoder = form.NewDecoder()
 
-// RequestParser decode request to object payload.
-// Only accept form JSON and form
+// RequestParser decode request to object payload, then validate it using `validate` tags.
+// Body is decoded based on its `Content-Type`: JSON, XML, CBOR, form and multipart form are supported,
+// see NewRequestParser to add other format. Unsupported format returns errors.ErrorUnsupportedMedia.
+// Querystring is merged too, body fields take precedence over it. See QueryParser for its format.
+// For JSON, XML and CBOR body, only fields with explicit `form` tag are taken from querystring.
+// Uploaded files are bound to UploadedFile fields, see NewRequestParser to limit them.
+// See package validation for available rules.
+//
+// When it is called within Handler which has WithParserOptions, those options are used.
 func RequestParser(r *http.Request, payload interface{}) error {
+	p, ok := r.Context().Value(parserCtxKey).(*parser)
+	if !ok {
+		p = defaultParser
+	}
+
+	return p.parse(r, payload)
+}
+
+// NewRequestParser returns RequestParser adjusted by opts.
+func NewRequestParser(opts ...ParserOption) func(r *http.Request, payload interface{}) error {
+	p := newParser(opts...)
+	return p.parse
+}
+
+type parser struct {
+	codecs       *CodecRegistry
+	decoders     map[string]BodyDecoder
+	maxBodyBytes int64
+	strictJSON   bool
+
+	// multipart
+	maxMemory    int64
+	maxFileSize  int64
+	allowedTypes []string
+	storeToDir   bool
+	dir          string
+	sink         UploadSink
+}
+
+var defaultParser = newParser()
+
+const parserCtxKey ctxKey = "http.parser"
+
+func newParser(opts ...ParserOption) *parser {
+	p := &parser{
+		codecs:    DefaultCodecs,
+		decoders:  map[string]BodyDecoder{},
+		maxMemory: 10 << 20,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *parser) parse(r *http.Request, payload interface{}) error {
+	if p.maxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(nil, r.Body, p.maxBodyBytes)
+	}
+
+	err := p.decode(r, payload)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	err = validation.Validate(payload)
+	if err != nil {
+		// nobody will use them
+		removeStoredFiles(boundFiles(payload))
+		return err
+	}
+
+	return nil
+}
+
+func (p *parser) decode(r *http.Request, payload interface{}) error {
 	contents := r.Header.Get("Content-Type")
-	if strings.Index(contents, "application/json") == 0 {
-		return json.NewDecoder(r.Body).Decode(payload)
-	} else if strings.Index(contents, "multipart/form-data") == 0 {
-		err := r.ParseMultipartForm(10 << 20)
+	if contents == "" {
+		// commonly GET or DELETE, which only have querystring
+		err := r.ParseForm()
 		if err != nil {
 			return err
 		}
 
-		// ignoring file
-		// any files will need manual process
-		return formDecoder.Decode(payload, r.MultipartForm.Value)
+		return decodeQuery(r.Form, payload)
 	}
 
-	err := r.ParseForm()
+	mediaType, params, err := mime.ParseMediaType(contents)
+	if err != nil {
+		return errors.ErrorUnsupportedMedia
+	}
+
+	if dec, ok := p.decoders[mediaType]; ok {
+		return dec(r, payload)
+	}
+
+	switch mediaType {
+	case "multipart/form-data":
+		if p.maxFileSize > 0 && params["boundary"] != "" {
+			body := limitFileParts(r, params["boundary"], p.maxFileSize)
+			// stops streaming when the form is not read to the end
+			defer body.Close()
+		}
+
+		err := r.ParseMultipartForm(p.maxMemory)
+		if err != nil {
+			return err
+		}
+
+		// r.Form contains both body and querystring values, body values come first
+		err = decodeQuery(r.Form, payload)
+		if err != nil {
+			return err
+		}
+
+		return p.bindFiles(r, payload)
+	case "application/x-www-form-urlencoded":
+		err := r.ParseForm()
+		if err != nil {
+			return err
+		}
+
+		return decodeQuery(r.Form, payload)
+	}
+
+	// structured syntax suffix, example: `application/vnd.api+json`
+	if strings.HasSuffix(mediaType, "+json") {
+		mediaType = "application/json"
+	}
+
+	codec, ok := p.codecs.Lookup(mediaType)
+	if !ok {
+		return errors.ErrorUnsupportedMedia
+	}
+
+	// querystring first, so body will overwrite the same fields
+	err = decodeQuery(formTagged(r.URL.Query(), payload), payload)
 	if err != nil {
 		return err
 	}
 
-	return formDecoder.Decode(payload, r.Form)
+	if _, isJSON := codec.(JSONCodec); isJSON && p.strictJSON {
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(payload)
+	} else {
+		err = codec.Decode(r.Body, payload)
+	}
+
+	// empty body is not an error, let validation decides
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
+}
+
+// decodeError turns failure caused by client into business error,
+// so client gets 4xx instead of masked 5xx.
+func decodeError(err error) error {
+	if _, ok := err.(*errors.Error); ok {
+		return err
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if stderrors.As(err, &maxBytesErr) {
+		e := errors.ErrorPayloadTooLarge.Copy()
+		e.AddField("body", fmt.Sprintf("must not exceed %d bytes", maxBytesErr.Limit))
+		return e
+	}
+
+	var fileErr *fileTooLargeError
+	if stderrors.As(err, &fileErr) {
+		e := errors.ErrorPayloadTooLarge.Copy()
+		e.AddField(fileErr.field, fileErr.Error())
+		return e
+	}
+
+	var formErrs form.DecodeErrors
+	if stderrors.As(err, &formErrs) {
+		e := errors.ErrorBadRequest.Copy()
+		for field, fieldErr := range formErrs {
+			e.AddField(field, fieldErr.Error())
+		}
+		return e
+	}
+
+	if isMalformedBody(err) {
+		e := errors.ErrorBadRequest.Copy()
+		e.AddField("body", err.Error())
+		return e
+	}
+
+	return err
+}
+
+func isMalformedBody(err error) bool {
+	if err == io.ErrUnexpectedEOF || err == http.ErrNotMultipart || err == http.ErrMissingBoundary ||
+		err == multipart.ErrMessageTooLarge {
+		return true
+	}
+
+	// encoding/json does not have specific type for unknown field
+	if strings.HasPrefix(err.Error(), "json: unknown field") {
+		return true
+	}
+
+	var (
+		jsonSyntaxErr *json.SyntaxError
+		jsonTypeErr   *json.UnmarshalTypeError
+		xmlSyntaxErr  *xml.SyntaxError
+		xmlErr        xml.UnmarshalError
+		cborSyntaxErr *cbor.SyntaxError
+		cborTypeErr   *cbor.UnmarshalTypeError
+		cborSemErr    *cbor.SemanticError
+	)
+
+	return stderrors.As(err, &jsonSyntaxErr) || stderrors.As(err, &jsonTypeErr) ||
+		stderrors.As(err, &xmlSyntaxErr) || stderrors.As(err, &xmlErr) ||
+		stderrors.As(err, &cborSyntaxErr) || stderrors.As(err, &cborTypeErr) || stderrors.As(err, &cborSemErr)
 }
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+type createItem struct {
+	Name  string `json:"name" xml:"name" form:"name" validate:"required"`
+	Count int    `json:"count" xml:"count" form:"count"`
+}
+
+func TestRequestParserBody(t *testing.T) {
+	custom := WithBodyDecoder("application/x-custom", func(r *http.Request, payload interface{}) error {
+		payload.(*createItem).Name = "custom"
+		return nil
+	})
+
+	tests := []struct {
+		name        string
+		opts        []ParserOption
+		contentType string
+		body        string
+		wantName    string
+		wantCode    errors.Code
+	}{
+		{"json", nil, "application/json", `{"name":"a","count":1}`, "a", 0},
+		{"json with charset", nil, "application/json; charset=utf-8", `{"name":"a"}`, "a", 0},
+		{"json suffix", nil, "application/vnd.api+json", `{"name":"a"}`, "a", 0},
+		{"xml", nil, "application/xml", `<item><name>a</name></item>`, "a", 0},
+		{"form", nil, "application/x-www-form-urlencoded", `name=a&count=1`, "a", 0},
+		{"custom decoder", []ParserOption{custom}, "application/x-custom", `whatever`, "custom", 0},
+		{"unsupported", nil, "text/plain", `a`, "", errors.Code842},
+		{"custom decoder is not registered", nil, "application/x-custom", `whatever`, "", errors.Code842},
+		{"malformed content type", nil, "application/", `{"name":"a"}`, "", errors.Code842},
+		{"malformed json", nil, "application/json", `{"name":`, "", errors.Code101},
+		{"wrong json type", nil, "application/json", `{"name":"a","count":"one"}`, "", errors.Code101},
+		{"unknown field", nil, "application/json", `{"name":"a","extra":1}`, "a", 0},
+		{"unknown field on strict json", []ParserOption{WithStrictJSON()}, "application/json", `{"name":"a","extra":1}`, "", errors.Code101},
+		{"body too large", []ParserOption{WithMaxBodyBytes(8)}, "application/json", `{"name":"abcdefgh"}`, "", errors.Code843},
+		{"empty body is validated", nil, "application/json", ``, "", errors.Code101},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			var payload createItem
+			err := NewRequestParser(tt.opts...)(r, &payload)
+			if tt.wantCode != 0 {
+				e, ok := err.(*errors.Error)
+				if !ok || e.Code != tt.wantCode {
+					t.Fatalf("err = %#v, want code %d", err, tt.wantCode)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if payload.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", payload.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestHandlerParserOptions(t *testing.T) {
+	endpoint := func(ctx context.Context, req *TypedRequest[createItem]) (createItem, int, error) {
+		return *req.Payload, http.StatusCreated, nil
+	}
+
+	s := NewServer()
+	s.Post("/default", NewTypedHandler(endpoint))
+	s.Post("/strict", NewTypedHandler(endpoint, WithParserOptions(WithStrictJSON())))
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"created", "/default", "application/json", `{"name":"a","extra":1}`, http.StatusCreated},
+		{"unsupported media type", "/default", "text/csv", `name\na`, http.StatusUnsupportedMediaType},
+		{"strict json of one handler", "/strict", "application/json", `{"name":"a","extra":1}`, http.StatusBadRequest},
+		{"strict handler still rejects unsupported", "/strict", "text/csv", `name\na`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			s.Handler().ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnsupportedMediaType && !strings.Contains(w.Body.String(), `"code":842`) {
+				t.Errorf("body = %s, want ErrorUnsupportedMedia", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequestParserMergesTaggedQueryOnly(t *testing.T) {
+	type updateUser struct {
+		Name    string            `json:"name"`
+		IsAdmin bool              `json:"is_admin"`
+		DryRun  bool              `form:"dry_run" json:"-"`
+		Filter  map[string]string `form:"filter" json:"-"`
+		Page    int               `json:"page" default:"1"`
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/?IsAdmin=true&is_admin=true&Name=b&dry_run=true&filter[status]=active",
+		strings.NewReader(`{"name":"a"}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	var payload updateUser
+	if err := RequestParser(r, &payload); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := updateUser{Name: "a", DryRun: true, Filter: map[string]string{"status": "active"}, Page: 1}
+	if payload.Name != want.Name || payload.IsAdmin || !payload.DryRun || payload.Filter["status"] != "active" || payload.Page != 1 {
+		t.Errorf("payload = %+v, want %+v", payload, want)
+	}
+}
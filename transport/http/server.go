@@ -1,14 +1,16 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
 
 	chi "github.com/go-chi/chi/v5"
 	chi_middleware "github.com/go-chi/chi/v5/middleware"
-	chi_cors "github.com/go-chi/cors"
+	"go.uber.org/zap"
 
+	"github.com/dee-el/go-fw/config"
 	"github.com/dee-el/go-fw/transport/http/middleware"
 )
 
@@ -17,13 +19,38 @@ import (
 // Example: `/users/{id}`
 //
 // Most handler registration functions using type `Handler`, a custom http.Handler.
-// Within `Handler`, the response will always return in JSON format.
+// Within `Handler`, the response will return in JSON format by default,
+// see NegotiatedResponseEncoder to pick format from `Accept` header.
 type Server struct {
 	mux                   *chi.Mux
 	enableBasicMiddleware bool
 	timeoutInSecond       time.Duration
-	// metrics               *middleware.Metrics
-	tracing *middleware.Tracing
+	metrics               *middleware.Metrics
+	tracing               *middleware.Tracing
+	otelTracing           *middleware.OTelTracing
+	accessLog             *middleware.AccessLog
+	logger                *zap.Logger
+	errorEncoder          ErrorEncoder
+	recoveryOpts          []middleware.RecoveryOption
+	enableMaintenance     bool
+	maintenance           *middleware.Maintenance
+	maintenanceCfg        *config.MaintenanceConfig
+	maintenanceOpts       []middleware.MaintenanceOption
+	cors                  CORSOptions
+	routeCORS             []routeCORS
+	policies              []Policy
+	mounts                []mountedPolicies
+	principalExtractor    PrincipalExtractor
+	trustedProxies        []string
+
+	// applied to every Handler registered to this server
+	handlerOpts []HandlerOption
+
+	// below are used by Run / ListenAndServe only
+	addr            string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	gracefulTimeout time.Duration
 }
 
 // NewServer returns new Server instance
@@ -31,35 +58,193 @@ func NewServer(opts ...ServerOption) *Server {
 	s := &Server{
 		timeoutInSecond:       time.Second * time.Duration(60),
 		enableBasicMiddleware: true,
+		addr:                  ":8080",
+		gracefulTimeout:       time.Second * time.Duration(10),
+		logger:                defaultLogger,
+		errorEncoder:          JSONErrorEncoder,
+		cors:                  DefaultCORSOptions,
+		principalExtractor:    middleware.PrincipalFromContext,
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	// server timeout goes first, so it can be overridden by WithHandlerOptions or WithTimeout of the Handler
+	s.handlerOpts = append([]HandlerOption{WithTimeout(s.timeoutInSecond)}, s.handlerOpts...)
+
 	s.mux = s.init()
 	return s
 }
 
 type ServerOption func(*Server)
 
+// WithTimeoutInSecond is an option to limit how long every request may take, default is 60 seconds.
+// Use WithTimeout on Handler to override it per route, zero means no timeout.
 func WithTimeoutInSecond(tm time.Duration) ServerOption {
 	return func(s *Server) {
 		s.timeoutInSecond = time.Second * tm
 	}
 }
 
+// WithConfig is an option to set up server from config.ServerConfig.
+// Zero values are ignored, so defaults will still be used for them.
+func WithConfig(cfg config.ServerConfig) ServerOption {
+	return func(s *Server) {
+		if cfg.Port != "" {
+			s.addr = cfg.Port
+			// allow port written without colon, example: `8080`
+			if !strings.Contains(s.addr, ":") {
+				s.addr = ":" + s.addr
+			}
+		}
+
+		if cfg.ReadTimeoutInSecond > 0 {
+			s.readTimeout = time.Second * time.Duration(cfg.ReadTimeoutInSecond)
+		}
+
+		if cfg.WriteTimeoutInSecond > 0 {
+			s.writeTimeout = time.Second * time.Duration(cfg.WriteTimeoutInSecond)
+		}
+
+		if cfg.GracefulTimeoutInSecond > 0 {
+			s.gracefulTimeout = time.Second * time.Duration(cfg.GracefulTimeoutInSecond)
+		}
+
+		if cfg.APITimeout > 0 {
+			s.timeoutInSecond = time.Second * time.Duration(cfg.APITimeout)
+		}
+
+		if len(cfg.CORS.AllowedOrigins) > 0 {
+			s.cors = CORSOptionsFromConfig(cfg.CORS)
+		}
+
+		for prefix, route := range cfg.CORS.Routes {
+			WithRouteCORS(prefix, CORSOptionsFromConfig(route))(s)
+		}
+
+		if len(cfg.TrustedProxies) > 0 {
+			s.trustedProxies = append(s.trustedProxies, cfg.TrustedProxies...)
+		}
+
+		if maintenanceConfigured(cfg.Maintenance) {
+			s.enableMaintenance = true
+			s.maintenanceCfg = &cfg.Maintenance
+		}
+	}
+}
+
+// WithRoutePolicy is an option to require policy on every route of server, including its Route.
+// It is useful for sub-server mounted by Mount, example: every route under `/admin` requires `admin` role.
+func WithRoutePolicy(p Policy) ServerOption {
+	return func(s *Server) {
+		s.policies = append(s.policies, p)
+	}
+}
+
+// WithPrincipalExtractor is an option to replace how policies read principal,
+// default is middleware.PrincipalFromContext which is set by middleware.Authentication.
+func WithPrincipalExtractor(extractor PrincipalExtractor) ServerOption {
+	return func(s *Server) {
+		s.principalExtractor = extractor
+	}
+}
+
+// WithCORS is an option to replace DefaultCORSOptions.
+// It panics on NewServer when credentials are allowed for any origin.
+func WithCORS(opts CORSOptions) ServerOption {
+	return func(s *Server) {
+		s.cors = opts
+	}
+}
+
+// WithRouteCORS is an option to override CORS for routes under prefix, example: public routes mounted by Route
+// can allow any origin, while the rest only allow the known ones.
+// The longest matching prefix wins.
+func WithRouteCORS(prefix string, opts CORSOptions) ServerOption {
+	return func(s *Server) {
+		s.routeCORS = append(s.routeCORS, routeCORS{prefix: prefix, options: opts})
+	}
+}
+
+// WithTrustedProxies is an option to read client IP from `X-Forwarded-For` or `X-Real-IP` header of requests sent by
+// proxies of given IPs or CIDRs, example: `10.0.0.0/8` of the load balancer, see middleware.RealIP.
+// By default no proxy is trusted, so client IP is always the peer address and can not be spoofed by headers.
+func WithTrustedProxies(proxies ...string) ServerOption {
+	return func(s *Server) {
+		s.trustedProxies = append(s.trustedProxies, proxies...)
+	}
+}
+
 func WithToggleBasicMiddleware(t bool) ServerOption {
 	return func(s *Server) {
 		s.enableBasicMiddleware = t
 	}
 }
 
-// func WithMetrics(m *middleware.Metrics) ServerOption {
-// 	return func(s *Server) {
-// 		s.metrics = m
-// 	}
-// }
+// WithMetrics is an option to record every inbound request.
+//
+// Example:
+//
+//	rec := prometheus.NewInboundHTTPRecorder()
+//	s := NewServer(WithMetrics(middleware.NewMetrics(rec)))
+//	s.MethodFunc(http.MethodGet, "/metrics", rec.Handler())
+func WithMetrics(m *middleware.Metrics) ServerOption {
+	return func(s *Server) {
+		s.metrics = m
+	}
+}
+
+// WithHandlerOptions is an option to apply opts to every Handler registered to server, including its Route.
+// Options given on NewHandler still take precedence.
+func WithHandlerOptions(opts ...HandlerOption) ServerOption {
+	return func(s *Server) {
+		s.handlerOpts = append(s.handlerOpts, opts...)
+	}
+}
+
+// WithDefaultErrorEncoder is an option to replace ErrorEncoder of every Handler registered to server,
+// example: ProblemJSONErrorEncoder.
+// It is used to write response of recovered panic too.
+func WithDefaultErrorEncoder(errorEncoder ErrorEncoder) ServerOption {
+	return func(s *Server) {
+		s.errorEncoder = errorEncoder
+		WithHandlerOptions(WithErrorEncoder(errorEncoder))(s)
+	}
+}
+
+// WithLogger is an option to replace logger of every Handler registered to server, including its Route.
+// The logger is used by LoggedErrorHandler and is available to endpoint through LoggerFromContext,
+// also used to log recovered panic.
+func WithLogger(logger *zap.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+		WithHandlerOptions(WithHandlerLogger(logger))(s)
+	}
+}
+
+// WithRecovery is an option to configure how panic is recovered, example: to add reporters or count panics.
+// By default panic is logged by server logger, then written by server error encoder,
+// opts can still override both of them.
+func WithRecovery(opts ...middleware.RecoveryOption) ServerOption {
+	return func(s *Server) {
+		s.recoveryOpts = append(s.recoveryOpts, opts...)
+	}
+}
+
+// WithMaintenance is an option to enable maintenance mode, see middleware.Maintenance.
+// By default error is written by server error encoder, opts can still override it.
+// Toggle it on runtime through Server.Maintenance, example: from NewMaintenanceUpdateHandler or config reload.
+//
+// Example:
+//
+//	s := NewServer(WithMaintenance(middleware.AllowedPathsMaintenanceOption("/health", "/admin/*")))
+func WithMaintenance(opts ...middleware.MaintenanceOption) ServerOption {
+	return func(s *Server) {
+		s.enableMaintenance = true
+		s.maintenanceOpts = append(s.maintenanceOpts, opts...)
+	}
+}
 
 func WithTracing(t *middleware.Tracing) ServerOption {
 	return func(s *Server) {
@@ -67,6 +252,25 @@ func WithTracing(t *middleware.Tracing) ServerOption {
 	}
 }
 
+// WithOTelTracing is an option to trace every inbound request using OpenTelemetry.
+//
+// Example:
+//
+//	s := NewServer(WithOTelTracing(middleware.NewOTelTracing(otel.GetTracerProvider())))
+func WithOTelTracing(t *middleware.OTelTracing) ServerOption {
+	return func(s *Server) {
+		s.otelTracing = t
+	}
+}
+
+// WithAccessLog is an option to log every request.
+// It is registered after tracing middleware, so trace ID is logged too.
+func WithAccessLog(a *middleware.AccessLog) ServerOption {
+	return func(s *Server) {
+		s.accessLog = a
+	}
+}
+
 // Server returns a http.Handler.
 func (s *Server) Handler() http.Handler {
 	return s.mux
@@ -78,100 +282,221 @@ func (s *Server) Middleware(fn func(next http.Handler) http.Handler) {
 }
 
 func (s *Server) Get(path string, hn *Handler) {
-	s.mux.Get(path, hn.ServeHTTP)
+	s.handle(http.MethodGet, path, hn)
 }
 
 func (s *Server) Head(path string, hn *Handler) {
-	s.mux.Head(path, hn.ServeHTTP)
+	s.handle(http.MethodHead, path, hn)
 }
 
 func (s *Server) Post(path string, hn *Handler) {
-	s.mux.Post(path, hn.ServeHTTP)
+	s.handle(http.MethodPost, path, hn)
 }
 
 func (s *Server) Put(path string, hn *Handler) {
-	s.mux.Put(path, hn.ServeHTTP)
+	s.handle(http.MethodPut, path, hn)
 }
 
 func (s *Server) Patch(path string, hn *Handler) {
-	s.mux.Patch(path, hn.ServeHTTP)
+	s.handle(http.MethodPatch, path, hn)
 }
 
 func (s *Server) Delete(path string, hn *Handler) {
-	s.mux.Delete(path, hn.ServeHTTP)
+	s.handle(http.MethodDelete, path, hn)
 }
 
 func (s *Server) Connect(path string, hn *Handler) {
-	s.mux.Connect(path, hn.ServeHTTP)
+	s.handle(http.MethodConnect, path, hn)
 }
 
 func (s *Server) Options(path string, hn *Handler) {
-	s.mux.Options(path, hn.ServeHTTP)
+	s.handle(http.MethodOptions, path, hn)
 }
 
 func (s *Server) Method(method, path string, hn *Handler) {
-	s.mux.Method(strings.ToUpper(method), path, hn)
+	s.handle(strings.ToUpper(method), path, hn)
+}
+
+// handle registers hn with options and policies of the server.
+func (s *Server) handle(method, path string, hn *Handler) {
+	hn = hn.inherit(s.handlerOpts...)
+	s.mux.Method(method, path, s.guard(hn, hn.policy, middlewareErrorEncoder(hn.errorEncoder)))
 }
 
+// Mount attaches sub under path, policies of the server (see WithRoutePolicy and RequirePolicy) are checked
+// on every route of sub too, as they are on Route.
 func (s *Server) Mount(path string, sub *Server) {
-	s.mux.Mount(path, sub.Handler())
+	if len(s.policies) == 0 {
+		s.mux.Mount(path, sub.Handler())
+		return
+	}
+
+	s.mounts = append(s.mounts, mountedPolicies{path: path, policies: append([]Policy{}, s.policies...)})
+	// chi builds the chain right away, so only policies required so far are checked, as on other routes
+	guard := func(next http.Handler) http.Handler {
+		return s.guard(next, nil, s.MiddlewareErrorEncoder())
+	}
+
+	// sub handler is still mounted as is, so its routes are walked by Routes
+	s.mux.With(guard).Mount(path, sub.Handler())
 }
 
 // MethodFunc is custom handler registration function.
 // User should use this if they want return other format instead of JSON.
 func (s *Server) MethodFunc(method, path string, hn http.Handler) {
-	s.mux.Method(strings.ToUpper(method), path, hn)
+	s.mux.Method(strings.ToUpper(method), path, s.guard(timeoutHandler(hn, s.timeoutInSecond), nil, s.MiddlewareErrorEncoder()))
+}
+
+// RequirePolicy requires p on every route registered after it, example: within Route
+//
+//	s.Route("/admin", func(sub *Server) {
+//		sub.RequirePolicy(RequireRoles("admin"))
+//		sub.Get("/users", listUsers)
+//	})
+func (s *Server) RequirePolicy(p Policy) {
+	s.policies = append(s.policies, p)
 }
 
 func (s *Server) Route(path string, fn func(sub *Server)) {
 	// every basic middleware should follow root / parent handler, no need initiate it multiple times on every child handler(s)
 	// well, user can do it tho if they want
-	sub := NewServer(WithToggleBasicMiddleware(false))
+	sub := NewServer(WithToggleBasicMiddleware(false), WithHandlerOptions(s.handlerOpts...), func(sub *Server) {
+		sub.timeoutInSecond = s.timeoutInSecond
+		sub.errorEncoder = s.errorEncoder
+		sub.policies = append([]Policy{}, s.policies...)
+		sub.principalExtractor = s.principalExtractor
+	})
 	fn(sub)
 
-	s.Mount(path, sub)
+	// sub already checks policies of the server on its own routes
+	s.mux.Mount(path, sub.Handler())
 }
 
 // all this middleware should be set even if some of these middleware are not needed
 func (s *Server) init() *chi.Mux {
 	mux := chi.NewRouter()
 
-	if s.enableBasicMiddleware {
-		mux = basicMiddleware(mux)
+	// middleware without ErrorEncoder given write their errors by the server one
+	errorEncoder := s.MiddlewareErrorEncoder()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(middleware.ContextWithErrorEncoder(r.Context(), errorEncoder)))
+		})
+	})
 
-		// Set a timeout value on the request context (ctx), that will signal
-		// through ctx.Done() that the request has timed out and further
-		// processing should be stopped.
-		mux.Use(chi_middleware.Timeout(s.timeoutInSecond))
+	// timeout is not set here, but on every registered handler instead, so it can be overridden per route
+	if s.enableBasicMiddleware {
+		mux = basicMiddleware(mux, corsMiddleware(s.cors, s.routeCORS), middleware.NewRealIP(s.trustedProxies...))
 	}
 
-	// if s.metrics != nil {
-	// 	mux.Use(s.metrics.Handler)
-	// }
+	if s.metrics != nil {
+		mux.Use(s.metrics.Handler)
+	}
 
 	if s.tracing != nil {
 		mux.Use(s.tracing.Handler)
 	}
 
+	if s.otelTracing != nil {
+		mux.Use(s.otelTracing.Handler)
+	}
+
+	if s.accessLog != nil {
+		mux.Use(s.accessLog.Handler)
+	}
+
+	// registered after access log, so rejected requests are still logged
+	if s.enableBasicMiddleware && s.enableMaintenance {
+		s.maintenance = s.newMaintenance()
+		mux.Use(s.maintenance.Handler)
+	}
+
+	// registered last, so panic is still seen as 500 by middleware above and the span is reachable from ctx
+	if s.enableBasicMiddleware {
+		mux.Use(s.recovery().Handler)
+	}
+
 	return mux
 }
 
-func basicMiddleware(mux *chi.Mux) *chi.Mux {
-	// for more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
-	cors := chi_cors.New(chi_cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Requested-With"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           120, // Maximum value not ignored by any of major browsers
-	})
-	mux.Use(cors.Handler)
+func basicMiddleware(mux *chi.Mux, cors func(next http.Handler) http.Handler, realIP *middleware.RealIP) *chi.Mux {
+	mux.Use(cors)
 
 	mux.Use(chi_middleware.RequestID)
-	mux.Use(chi_middleware.RealIP)
-
-	mux.Use(middleware.Recoverer)
+	mux.Use(realIP.Handler)
 
 	return mux
 }
+
+// timeoutHandler sets timeout on ctx of request, that will signal through ctx.Done()
+// that the request has timed out and further processing should be stopped.
+func timeoutHandler(next http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// MiddlewareErrorEncoder returns ErrorEncoder of server (see WithDefaultErrorEncoder) to be used by middleware,
+// so their errors are written in the same format as Handler. Middleware registered on the server already use it
+// when they have no ErrorEncoder given, this is to use it elsewhere, example: on middleware of other router
+//
+//	rl := middleware.NewRateLimit(store, policy, middleware.ErrorEncoderRateLimitOption(s.MiddlewareErrorEncoder()))
+func (s *Server) MiddlewareErrorEncoder() middleware.ErrorEncoder {
+	return middlewareErrorEncoder(s.errorEncoder)
+}
+
+func middlewareErrorEncoder(encoder ErrorEncoder) middleware.ErrorEncoder {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		// so the encoder can look into the request, as it does within Handler
+		ctx := context.WithValue(r.Context(), requestCtxKey, r)
+		encoder(ctx, w, err)
+	}
+}
+
+func (s *Server) recovery() *middleware.Recovery {
+	opts := []middleware.RecoveryOption{
+		middleware.LoggerRecoveryOption(s.logger),
+		middleware.ErrorEncoderRecoveryOption(s.MiddlewareErrorEncoder()),
+	}
+
+	return middleware.NewRecovery(append(opts, s.recoveryOpts...)...)
+}
+
+// Maintenance returns maintenance middleware of server to toggle it on runtime,
+// it is nil when neither WithMaintenance nor maintenance config is set.
+//
+// Example, on config reload:
+//
+//	file.Watch(func(cfg *config.Config, err error) {
+//		if err == nil {
+//			err = s.Maintenance().ApplyConfig(cfg.Server.Maintenance)
+//		}
+//		...
+//	})
+func (s *Server) Maintenance() *middleware.Maintenance {
+	return s.maintenance
+}
+
+func (s *Server) newMaintenance() *middleware.Maintenance {
+	opts := []middleware.MaintenanceOption{
+		middleware.ErrorEncoderMaintenanceOption(s.MiddlewareErrorEncoder()),
+	}
+
+	if s.maintenanceCfg != nil {
+		opts = append(opts, middleware.ConfigMaintenanceOption(*s.maintenanceCfg))
+	}
+
+	return middleware.NewMaintenance(append(opts, s.maintenanceOpts...)...)
+}
+
+func maintenanceConfigured(cfg config.MaintenanceConfig) bool {
+	return cfg.Enabled || cfg.RetryAfterInSecond > 0 || len(cfg.AllowedPaths) > 0 || len(cfg.BypassIPs) > 0 ||
+		cfg.Start != "" || cfg.End != ""
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/metrics"
+	"github.com/dee-el/go-fw/tracederr"
+	"github.com/dee-el/go-fw/transport/http/middleware"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+func TestMiddlewareErrorsGoThroughServerErrorEncoder(t *testing.T) {
+	forbidden := middleware.AuthenticatorFunc(func(r *http.Request) (*middleware.Principal, error) {
+		return nil, errors.ErrorForbidden
+	})
+
+	maintenance := middleware.NewMaintenance()
+	maintenance.Enable()
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		wantStatus int
+		wantCode   string
+	}{
+		{"authentication", middleware.NewAuthentication(forbidden).Handler, http.StatusForbidden, `"code":825`},
+		{"rate limit", middleware.NewRateLimit(middleware.NewMemoryRateLimitStore(), middleware.RateLimitPolicy{
+			Algorithm: middleware.SlidingWindow, Limit: 1, Window: time.Minute,
+		}).Handler, http.StatusTooManyRequests, `"code":831`},
+		{"maintenance", maintenance.Handler, http.StatusServiceUnavailable, `"code":910`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(WithDefaultErrorEncoder(ProblemJSONErrorEncoder))
+			s.Middleware(tt.middleware)
+			s.Get("/", okHandler())
+
+			var w *httptest.ResponseRecorder
+			// rate limit lets the first request through
+			for i := 0; i < 2; i++ {
+				w = httptest.NewRecorder()
+				s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Fatalf("Content-Type = %q, want application/problem+json", got)
+			}
+
+			if !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Fatalf("body = %s, want it contains %s", w.Body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMaintenanceBypassThroughServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []ServerOption
+		remoteAddr string
+		header     http.Header
+		wantStatus int
+	}{
+		{"spoofed X-Real-IP", nil, "192.0.2.1:1234", http.Header{"X-Real-Ip": {"10.1.2.3"}}, http.StatusServiceUnavailable},
+		{"spoofed X-Forwarded-For", nil, "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"10.1.2.3"}}, http.StatusServiceUnavailable},
+		{"bypassed peer", nil, "10.1.2.3:1234", nil, http.StatusOK},
+		{"spoofed through untrusted proxy", []ServerOption{WithTrustedProxies("172.16.0.1")},
+			"192.0.2.1:1234", http.Header{"X-Forwarded-For": {"10.1.2.3"}}, http.StatusServiceUnavailable},
+		{"forwarded by trusted proxy", []ServerOption{WithTrustedProxies("172.16.0.1")},
+			"172.16.0.1:1234", http.Header{"X-Forwarded-For": {"10.1.2.3"}}, http.StatusOK},
+		{"spoofed before trusted proxy", []ServerOption{WithTrustedProxies("172.16.0.1")},
+			"172.16.0.1:1234", http.Header{"X-Forwarded-For": {"10.1.2.3, 192.0.2.1"}}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]ServerOption{WithMaintenance(middleware.BypassIPsMaintenanceOption("10.0.0.0/8"))}, tt.opts...)
+			s := NewServer(opts...)
+			s.Get("/", okHandler())
+			s.Maintenance().Enable()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRateLimitByIPThroughServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []ServerOption
+		remoteAddr string
+		forwarded  []string
+		wantStatus []int
+	}{
+		{"rotating headers from client", nil, "192.0.2.1:1234", []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"},
+			[]int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}},
+		{"clients behind trusted proxy", []ServerOption{WithTrustedProxies("10.0.0.1")}, "10.0.0.1:1234",
+			[]string{"198.51.100.1", "198.51.100.2", "198.51.100.1"},
+			[]int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(tt.opts...)
+			s.Middleware(middleware.NewRateLimit(middleware.NewMemoryRateLimitStore(), middleware.RateLimitPolicy{
+				Algorithm: middleware.SlidingWindow, Limit: 1, Window: time.Minute,
+			}).Handler)
+			s.Get("/", okHandler())
+
+			for i, forwarded := range tt.forwarded {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.RemoteAddr = tt.remoteAddr
+				r.Header.Set("X-Forwarded-For", forwarded)
+
+				w := httptest.NewRecorder()
+				s.Handler().ServeHTTP(w, r)
+				if w.Code != tt.wantStatus[i] {
+					t.Fatalf("request %d: status = %d, want %d", i, w.Code, tt.wantStatus[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecoveryThroughServer(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	counter := metrics.NewMemoryRecorder()
+	tracer := mocktracer.New()
+
+	var reported []*tracederr.Error
+	reporter := middleware.PanicReporterFunc(func(ctx context.Context, r *http.Request, err *tracederr.Error) {
+		reported = append(reported, err)
+	})
+
+	s := NewServer(
+		WithLogger(zap.New(core)),
+		WithDefaultErrorEncoder(ProblemJSONErrorEncoder),
+		WithTracing(middleware.NewTracing(tracer)),
+		WithRecovery(middleware.CounterRecoveryOption(counter), middleware.ReportersRecoveryOption(reporter)),
+	)
+	s.Get("/items/{id}", NewHandler(func(ctx context.Context, request *Request) (response.Response, int, error) {
+		panic("boom")
+	}, nopRequestDecoder))
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want server error encoder", got)
+	}
+	if !strings.Contains(w.Body.String(), `"code":901`) {
+		t.Errorf("body = %s, want ErrorInternalServer", w.Body)
+	}
+
+	if got := counter.Counter(middleware.MetricPanicsTotal, metrics.Labels{"path": "/items/{id}", "method": http.MethodGet}); got != 1 {
+		t.Errorf("%s = %v, want 1", middleware.MetricPanicsTotal, got)
+	}
+
+	if len(reported) != 1 || reported[0].Error() != "panic: boom" {
+		t.Errorf("reported = %v, want the panic", reported)
+	}
+
+	entries := logs.FilterMessage("HTTP panic").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d panic logs, want 1", len(entries))
+	}
+	if got := entries[0].ContextMap()["request_path"]; got != "/items/{id}" {
+		t.Errorf("request_path = %v, want route pattern", got)
+	}
+
+	spans := tracer.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if tags := spans[0].Tags(); tags["error"] != true || tags["error.kind"] != "internal" {
+		t.Errorf("span tags = %v, want internal error", tags)
+	}
+}
@@ -0,0 +1,17 @@
+package http
+
+import (
+	"context"
+
+	"github.com/dee-el/go-fw/transport/http/middleware"
+)
+
+// recordSpanError attaches err to the active span of ctx, so failed requests can be searched on traces.
+// Both OpenTracing and OpenTelemetry spans are supported.
+// HTTP status of err is taken from Dictionary, so only 5xx errors mark OpenTelemetry span as failed.
+func recordSpanError(ctx context.Context, err error) {
+	_, status := errorResponse(err)
+
+	middleware.TagSpanError(ctx, err)
+	middleware.RecordOTelError(ctx, err, status)
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/dee-el/go-fw/errors"
+	"github.com/dee-el/go-fw/transport/http/middleware"
+	"github.com/dee-el/go-fw/transport/http/middleware/oteltest"
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+func TestOTelSpanStatusFollowsErrorStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+	}{
+		{"not found", errors.ErrorNotFound, codes.Unset},
+		{"bad request", errors.ErrorBadRequest, codes.Unset},
+		{"business 5xx", errors.ErrorMaintenance, codes.Error},
+		{"internal", stderrors.New("db down"), codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, exp := oteltest.NewTracerProvider()
+			s := NewServer(WithOTelTracing(middleware.NewOTelTracing(tp)))
+			s.Get("/", NewHandler(func(ctx context.Context, request *Request) (response.Response, int, error) {
+				return response.Response{}, 0, tt.err
+			}, nopRequestDecoder))
+
+			s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			spans := exp.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+
+			span := spans[0]
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+
+			// business errors are still recorded
+			if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+				t.Errorf("events = %v, want one exception", span.Events)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/dee-el/go-fw/transport/http/response"
+)
+
+// TypedRequest is like Request, but Payload already decoded into Req.
+type TypedRequest[Req any] struct {
+	Payload   *Req
+	URLParams URLParams
+	Query     url.Values
+}
+
+// TypedEndpoint is Endpoint with compile-time request and response types.
+// Returned Resp will be wrapped into `response.Response` as Data, zero httpStatus means 200.
+type TypedEndpoint[Req, Resp any] func(ctx context.Context, request *TypedRequest[Req]) (resp Resp, httpStatus int, err error)
+
+// NewTypedHandler returns Handler which decodes request straight into Req via RequestParser,
+// so payload mismatches become compile errors instead of failed type assertion on runtime.
+//
+// Example:
+//
+//	hn := NewTypedHandler(func(ctx context.Context, req *TypedRequest[CreateUser]) (User, int, error) {
+//		...
+//	})
+func NewTypedHandler[Req, Resp any](endpoint TypedEndpoint[Req, Resp], opts ...HandlerOption) *Handler {
+	return NewHandler(typedEndpoint(endpoint), TypedRequestDecoder[Req](), opts...)
+}
+
+// TypedRequestDecoder returns RequestDecoder which decodes into Req.
+// The Payload of returned Request will be *TypedRequest[Req].
+func TypedRequestDecoder[Req any]() RequestDecoder {
+	return func(ctx context.Context, r *http.Request) (*Request, error) {
+		payload := new(Req)
+		err := RequestParser(r, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Request{
+			Payload: &TypedRequest[Req]{
+				Payload: payload,
+			},
+		}, nil
+	}
+}
+
+func typedEndpoint[Req, Resp any](endpoint TypedEndpoint[Req, Resp]) Endpoint {
+	return func(ctx context.Context, request *Request) (response.Response, int, error) {
+		// this is safe since payload always created by TypedRequestDecoder
+		req := request.Payload.(*TypedRequest[Req])
+		req.URLParams = request.URLParams
+		req.Query = request.Query
+
+		resp, httpStatus, err := endpoint(ctx, req)
+		if err != nil {
+			return response.Response{}, httpStatus, err
+		}
+
+		if httpStatus == 0 {
+			httpStatus = http.StatusOK
+		}
+
+		return *response.NewResponse(resp, nil), httpStatus, nil
+	}
+}
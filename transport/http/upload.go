@@ -0,0 +1,352 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// UploadedFile is a file sent through multipart form.
+// Declare it on payload struct using `form` tag, then RequestParser will bind it.
+//
+// Example:
+//
+//	type UploadAvatar struct {
+//		UserID      string          `form:"user_id"`
+//		Avatar      *UploadedFile   `form:"avatar"`
+//		Attachments []*UploadedFile `form:"attachments"`
+//	}
+type UploadedFile struct {
+	Filename string
+	Size     int64
+	Header   textproto.MIMEHeader
+	// ContentType is sniffed from file content, not what client claims on Header.
+	ContentType string
+	// Path is set when file is stored to upload dir, see WithUploadDir.
+	Path string
+
+	fh *multipart.FileHeader
+}
+
+// Open opens the stored file when Path is set, otherwise the original multipart file.
+func (f *UploadedFile) Open() (multipart.File, error) {
+	if f.Path != "" {
+		return os.Open(f.Path)
+	}
+
+	return f.fh.Open()
+}
+
+// UploadSink receives content of each uploaded file, so user can stream it directly to other storage, example: S3.
+// Returning *errors.Error will be sent to client as is.
+type UploadSink func(ctx context.Context, field string, file *UploadedFile, r io.Reader) error
+
+// ParserOption is an option to adjust how request is parsed by NewRequestParser.
+type ParserOption func(p *parser)
+
+// WithUploadMaxMemory sets maximum bytes of multipart form stored on memory, the rest are stored on temporary files.
+// Default is 10 MB.
+func WithUploadMaxMemory(n int64) ParserOption {
+	return func(p *parser) {
+		p.maxMemory = n
+	}
+}
+
+// WithUploadMaxFileSize sets maximum size of each uploaded file in bytes. Default is no limit.
+// Every file is checked while being read, so oversized one is rejected with errors.ErrorPayloadTooLarge
+// before it is stored on disk. Use WithMaxBodyBytes to limit the whole body.
+func WithUploadMaxFileSize(n int64) ParserOption {
+	return func(p *parser) {
+		p.maxFileSize = n
+	}
+}
+
+// WithUploadAllowedTypes sets which content types allowed to be uploaded, example: `image/png`, `image/*`.
+// Content type is sniffed from file content. Default is any content type.
+func WithUploadAllowedTypes(types ...string) ParserOption {
+	return func(p *parser) {
+		p.allowedTypes = append(p.allowedTypes, types...)
+	}
+}
+
+// WithUploadDir stores every uploaded file into dir, then sets its Path.
+// Empty dir means default temporary dir. Stored files are removed when request fails to be parsed or validated,
+// otherwise removing them is user responsibility.
+func WithUploadDir(dir string) ParserOption {
+	return func(p *parser) {
+		p.storeToDir = true
+		p.dir = dir
+	}
+}
+
+// WithUploadSink streams every uploaded file into sink. It takes precedence over WithUploadDir.
+func WithUploadSink(sink UploadSink) ParserOption {
+	return func(p *parser) {
+		p.sink = sink
+	}
+}
+
+func (p *parser) bindFiles(r *http.Request, payload interface{}) error {
+	rv := reflect.ValueOf(payload)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	// every file stored to upload dir, removed when any of them fails
+	var stored []*UploadedFile
+	fail := func(err error) error {
+		removeStoredFiles(stored)
+		return err
+	}
+
+	var invalid *errors.Error
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() || (f.Type != fileType && f.Type != filesType) {
+			continue
+		}
+
+		key, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+
+		headers := r.MultipartForm.File[key]
+		if len(headers) == 0 {
+			continue
+		}
+
+		files := make([]*UploadedFile, 0, len(headers))
+		for _, fh := range headers {
+			file, msg, err := p.processFile(r.Context(), key, fh)
+			if file != nil && file.Path != "" {
+				stored = append(stored, file)
+			}
+
+			if err != nil {
+				return fail(err)
+			}
+
+			if msg != "" {
+				if invalid == nil {
+					invalid = errors.ErrorBadRequest.Copy()
+				}
+				invalid.AddField(key, msg)
+				break
+			}
+
+			files = append(files, file)
+		}
+
+		if len(files) != len(headers) {
+			continue
+		}
+
+		if f.Type == fileType {
+			rv.Field(i).Set(reflect.ValueOf(files[0]))
+		} else {
+			rv.Field(i).Set(reflect.ValueOf(files))
+		}
+	}
+
+	if invalid != nil {
+		return fail(invalid)
+	}
+
+	return nil
+}
+
+// boundFiles returns files bound to payload by bindFiles.
+func boundFiles(payload interface{}) []*UploadedFile {
+	rv := reflect.ValueOf(payload)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	rv = rv.Elem()
+	var files []*UploadedFile
+	for i := 0; i < rv.NumField(); i++ {
+		switch v := rv.Field(i); v.Type() {
+		case fileType:
+			if !v.IsNil() {
+				files = append(files, v.Interface().(*UploadedFile))
+			}
+		case filesType:
+			files = append(files, v.Interface().([]*UploadedFile)...)
+		}
+	}
+
+	return files
+}
+
+func removeStoredFiles(files []*UploadedFile) {
+	for _, f := range files {
+		if f != nil && f.Path != "" {
+			os.Remove(f.Path)
+		}
+	}
+}
+
+var (
+	fileType  = reflect.TypeOf(&UploadedFile{})
+	filesType = reflect.TypeOf([]*UploadedFile{})
+)
+
+// fileTooLargeError is returned while reading multipart body when a file exceeds WithUploadMaxFileSize.
+type fileTooLargeError struct {
+	field    string
+	filename string
+	limit    int64
+}
+
+func (e *fileTooLargeError) Error() string {
+	return fmt.Sprintf("file %s exceeds maximum size of %d bytes", e.filename, e.limit)
+}
+
+// limitFileParts replaces body of r with the same multipart body, streamed part by part,
+// which fails with fileTooLargeError as soon as a file exceeds limit. Returned closer stops the streaming.
+func limitFileParts(r *http.Request, boundary string, limit int64) io.Closer {
+	src := multipart.NewReader(r.Body, boundary)
+	pr, pw := io.Pipe()
+
+	go func() {
+		mw := multipart.NewWriter(pw)
+		// never fails, since boundary is already accepted by the reader
+		mw.SetBoundary(boundary)
+		pw.CloseWithError(copyFileParts(src, mw, limit))
+	}()
+
+	r.Body = pr
+	return pr
+}
+
+func copyFileParts(src *multipart.Reader, dst *multipart.Writer, limit int64) error {
+	for {
+		part, err := src.NextRawPart()
+		if err == io.EOF {
+			return dst.Close()
+		}
+		if err != nil {
+			return err
+		}
+
+		w, err := dst.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+
+		if part.FileName() == "" {
+			if _, err := io.Copy(w, part); err != nil {
+				return err
+			}
+			continue
+		}
+
+		n, err := io.Copy(w, io.LimitReader(part, limit+1))
+		if err != nil {
+			return err
+		}
+		if n > limit {
+			return &fileTooLargeError{field: part.FormName(), filename: part.FileName(), limit: limit}
+		}
+	}
+}
+
+// processFile returns message when file is not acceptable
+func (p *parser) processFile(ctx context.Context, field string, fh *multipart.FileHeader) (*UploadedFile, string, error) {
+	if p.maxFileSize > 0 && fh.Size > p.maxFileSize {
+		return nil, fmt.Sprintf("file %s exceeds maximum size of %d bytes", fh.Filename, p.maxFileSize), nil
+	}
+
+	src, err := fh.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer src.Close()
+
+	// as http.DetectContentType only considers the first 512 bytes
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(src, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, "", err
+	}
+
+	file := &UploadedFile{
+		Filename:    fh.Filename,
+		Size:        fh.Size,
+		Header:      fh.Header,
+		ContentType: http.DetectContentType(sniff[:n]),
+		fh:          fh,
+	}
+
+	if !p.isAllowedType(file.ContentType) {
+		return nil, fmt.Sprintf("file %s has disallowed content type %s", fh.Filename, file.ContentType), nil
+	}
+
+	_, err = src.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if p.sink != nil {
+		return file, "", p.sink(ctx, field, file, src)
+	}
+
+	if p.storeToDir {
+		dst, err := os.CreateTemp(p.dir, "upload-*"+filepath.Ext(fh.Filename))
+		if err != nil {
+			return nil, "", err
+		}
+		defer dst.Close()
+
+		_, err = io.Copy(dst, src)
+		if err != nil {
+			os.Remove(dst.Name())
+			return nil, "", err
+		}
+
+		file.Path = dst.Name()
+	}
+
+	return file, "", nil
+}
+
+func (p *parser) isAllowedType(contentType string) bool {
+	if len(p.allowedTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range p.allowedTypes {
+		if allowed == mediaType || allowed == "*/*" {
+			return true
+		}
+
+		// wildcard subtype, example: `image/*`
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+type uploadPayload struct {
+	Name        string          `form:"name" validate:"required"`
+	Attachments []*UploadedFile `form:"attachments"`
+}
+
+type part struct {
+	field, filename, content string
+}
+
+func multipartRequest(t *testing.T, parts ...part) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		if p.filename == "" {
+			mw.WriteField(p.field, p.content)
+			continue
+		}
+
+		w, err := mw.CreateFormFile(p.field, p.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(p.content))
+	}
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestUpload(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 10)
+
+	tests := []struct {
+		name      string
+		opts      []ParserOption
+		parts     []part
+		wantCode  errors.Code
+		wantFiles int
+	}{
+		{"stored", nil, []part{{"name", "", "a"}, {"attachments", "a.png", png}, {"attachments", "b.png", png}}, 0, 2},
+		{"file too large is rejected while read", []ParserOption{WithUploadMaxFileSize(100), WithUploadMaxMemory(100)},
+			[]part{{"name", "", "a"}, {"attachments", "a.txt", strings.Repeat("x", 1000)}}, errors.Code843, 0},
+		{"file too large within body limit", []ParserOption{WithUploadMaxFileSize(100), WithMaxBodyBytes(10000)},
+			[]part{{"name", "", "a"}, {"attachments", "a.txt", strings.Repeat("x", 1000)}}, errors.Code843, 0},
+		{"many files each within limit", []ParserOption{WithUploadMaxFileSize(1000), WithUploadMaxMemory(100)},
+			[]part{{"name", "", "a"}, {"attachments", "a.txt", strings.Repeat("x", 900)}, {"attachments", "b.txt", strings.Repeat("x", 900)},
+				{"attachments", "c.txt", strings.Repeat("x", 900)}}, 0, 3},
+		{"file exactly at limit", []ParserOption{WithUploadMaxFileSize(1000)},
+			[]part{{"name", "", "a"}, {"attachments", "a.txt", strings.Repeat("x", 1000)}}, 0, 1},
+		{"second file too large", []ParserOption{WithUploadMaxFileSize(1000)},
+			[]part{{"name", "", "a"}, {"attachments", "a.txt", strings.Repeat("x", 900)}, {"attachments", "b.txt", strings.Repeat("x", 1001)}}, errors.Code843, 0},
+		{"whole body limit", []ParserOption{WithUploadMaxFileSize(1000), WithMaxBodyBytes(2000)},
+			[]part{{"name", "", "a"}, {"attachments", "a.txt", strings.Repeat("x", 900)}, {"attachments", "b.txt", strings.Repeat("x", 900)},
+				{"attachments", "c.txt", strings.Repeat("x", 900)}}, errors.Code843, 0},
+		{"disallowed type removes stored files", []ParserOption{WithUploadAllowedTypes("image/png")},
+			[]part{{"name", "", "a"}, {"attachments", "a.png", png}, {"attachments", "b.txt", "hello"}}, errors.Code101, 0},
+		{"invalid payload removes stored files", nil,
+			[]part{{"attachments", "a.png", png}}, errors.Code101, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			parse := NewRequestParser(append([]ParserOption{WithUploadDir(dir)}, tt.opts...)...)
+
+			var payload uploadPayload
+			err := parse(multipartRequest(t, tt.parts...), &payload)
+			if tt.wantCode == 0 && err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if tt.wantCode != 0 {
+				e, ok := err.(*errors.Error)
+				if !ok || e.Code != tt.wantCode {
+					t.Fatalf("err = %#v, want code %d", err, tt.wantCode)
+				}
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != tt.wantFiles {
+				t.Fatalf("stored files = %d, want %d", len(entries), tt.wantFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+// TagName is struct tag used to declare rules.
+//
+// Example:
+//
+//	type CreateUser struct {
+//		Name    string   `json:"name" validate:"required,min=3,max=50"`
+//		Email   string   `json:"email" validate:"required,email"`
+//		Role    string   `json:"role" validate:"enum=admin|member"`
+//		Phone   string   `json:"phone" validate:"regex=^\\+?[0-9]+$"`
+//		Address Address  `json:"address"`
+//		Tags    []string `json:"tags" validate:"max=5"`
+//		Age     int      `json:"age" validate:"min=18"`
+//		Score   int      `json:"score" validate:"omitempty,min=10"`
+//	}
+//
+// Rules are separated by comma, `regex` takes the rest of the tag so it should be placed last.
+// Empty value which is not `required` will skip all other rules, except zero number, since 0 is a value:
+// `min=18` rejects 0. Use `omitempty` to skip rules on zero number too.
+// Nested structs (also non-nil pointers and slices of them) are always validated.
+const TagName = "validate"
+
+// RuleFunc checks value against param of rule, example: `min=3` has param `3`.
+// Returned string is the message for invalid field, empty means valid.
+type RuleFunc func(v reflect.Value, param string) string
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]RuleFunc{
+		"min":   ruleMin,
+		"max":   ruleMax,
+		"len":   ruleLen,
+		"enum":  ruleEnum,
+		"email": ruleEmail,
+		"regex": ruleRegex,
+	}
+)
+
+// RegisterRule adds custom rule or replaces existing one.
+// `required` and `omitempty` can not be replaced.
+func RegisterRule(name string, fn RuleFunc) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+
+	rules[name] = fn
+}
+
+// Validate checks struct (or pointer to struct) v using `validate` tags.
+// When some fields are invalid, it returns copy of errors.ErrorBadRequest with one Fields entry per invalid field,
+// key is field name taken from `json` tag, `form` tag or struct field name, nested fields are joined by dot.
+// Unknown rule or invalid regex returns plain error since it is mistake on code.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var invalid errors.Fields
+	err := validateStruct(rv, "", func(field, msg string) {
+		if invalid == nil {
+			invalid = errors.Fields{}
+		}
+		invalid[field] = msg
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(invalid) == 0 {
+		return nil
+	}
+
+	e := errors.ErrorBadRequest.Copy()
+	e.Fields = invalid
+	return e
+}
+
+type fieldRule struct {
+	name  string
+	param string
+}
+
+type fieldSpec struct {
+	index     int
+	name      string
+	required  bool
+	omitEmpty bool
+	rules     []fieldRule
+}
+
+var specCache sync.Map // reflect.Type -> []fieldSpec
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func validateStruct(rv reflect.Value, prefix string, report func(field, msg string)) error {
+	specs, err := structSpecs(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range specs {
+		fv := rv.Field(spec.index)
+		name := prefix + spec.name
+
+		if fv.IsZero() {
+			if spec.required {
+				report(name, "is required")
+				continue
+			}
+
+			if spec.omitEmpty || !isNumber(fv) {
+				// empty struct value still has to satisfy its own rules
+				if fv.Kind() == reflect.Struct {
+					err = validateNested(fv, name, report)
+					if err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		}
+
+		valid := true
+		for _, r := range spec.rules {
+			rulesMu.RLock()
+			fn, ok := rules[r.name]
+			rulesMu.RUnlock()
+			if !ok {
+				return fmt.Errorf("validation: unknown rule %q on field %s", r.name, name)
+			}
+
+			msg := fn(indirect(fv), r.param)
+			if msg != "" {
+				report(name, msg)
+				valid = false
+				break
+			}
+		}
+
+		if valid {
+			err = validateNested(fv, name, report)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateNested(fv reflect.Value, name string, report func(field, msg string)) error {
+	fv = indirect(fv)
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() == timeType {
+			return nil
+		}
+		return validateStruct(fv, name+".", report)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			item := indirect(fv.Index(i))
+			if item.Kind() != reflect.Struct || item.Type() == timeType {
+				continue
+			}
+
+			err := validateStruct(item, fmt.Sprintf("%s[%d].", name, i), report)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func structSpecs(t reflect.Type) ([]fieldSpec, error) {
+	if cached, ok := specCache.Load(t); ok {
+		return cached.([]fieldSpec), nil
+	}
+
+	var specs []fieldSpec
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+
+		spec := fieldSpec{
+			index: i,
+			name:  fieldName(f),
+		}
+
+		for tag != "" {
+			var part string
+			// regex takes the rest, since pattern may contain comma
+			if strings.HasPrefix(tag, "regex=") {
+				part, tag = tag, ""
+			} else {
+				part, tag, _ = strings.Cut(tag, ",")
+			}
+
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			name, param, _ := strings.Cut(part, "=")
+			if name == "required" {
+				spec.required = true
+				continue
+			}
+
+			if name == "omitempty" {
+				spec.omitEmpty = true
+				continue
+			}
+
+			if name == "regex" {
+				_, err := compileRegex(param)
+				if err != nil {
+					return nil, fmt.Errorf("validation: invalid regex on field %s: %w", f.Name, err)
+				}
+			}
+
+			spec.rules = append(spec.rules, fieldRule{name: name, param: param})
+		}
+
+		specs = append(specs, spec)
+	}
+
+	specCache.Store(t, specs)
+	return specs, nil
+}
+
+func fieldName(f reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return f.Name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// size returns number to compare on min, max and len.
+// For string, slice and map it is the length, for numbers it is the value itself.
+func size(v reflect.Value) (float64, bool, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	}
+
+	return 0, false, false
+}
+
+func ruleMin(v reflect.Value, param string) string {
+	n, isLen, ok := size(v)
+	limit, err := strconv.ParseFloat(param, 64)
+	if !ok || err != nil {
+		return ""
+	}
+
+	if n < limit {
+		if isLen {
+			return "length must be at least " + param
+		}
+		return "must be at least " + param
+	}
+
+	return ""
+}
+
+func ruleMax(v reflect.Value, param string) string {
+	n, isLen, ok := size(v)
+	limit, err := strconv.ParseFloat(param, 64)
+	if !ok || err != nil {
+		return ""
+	}
+
+	if n > limit {
+		if isLen {
+			return "length must be at most " + param
+		}
+		return "must be at most " + param
+	}
+
+	return ""
+}
+
+func ruleLen(v reflect.Value, param string) string {
+	n, isLen, ok := size(v)
+	limit, err := strconv.ParseFloat(param, 64)
+	if !ok || !isLen || err != nil {
+		return ""
+	}
+
+	if n != limit {
+		return "length must be " + param
+	}
+
+	return ""
+}
+
+func ruleEnum(v reflect.Value, param string) string {
+	options := strings.Split(param, "|")
+	val := fmt.Sprint(v.Interface())
+	for _, opt := range options {
+		if val == opt {
+			return ""
+		}
+	}
+
+	return "must be one of " + strings.Join(options, ", ")
+}
+
+func ruleEmail(v reflect.Value, _ string) string {
+	if v.Kind() != reflect.String {
+		return ""
+	}
+
+	addr, err := mail.ParseAddress(v.String())
+	// reject `Name <email>` format, only plain address allowed
+	if err != nil || addr.Address != v.String() {
+		return "must be a valid email"
+	}
+
+	return ""
+}
+
+var regexCache sync.Map // string -> *regexp.Regexp
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
+func ruleRegex(v reflect.Value, param string) string {
+	if v.Kind() != reflect.String {
+		return ""
+	}
+
+	re, err := compileRegex(param)
+	if err != nil || !re.MatchString(v.String()) {
+		return "must match pattern " + param
+	}
+
+	return ""
+}
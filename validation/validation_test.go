@@ -0,0 +1,122 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dee-el/go-fw/errors"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type user struct {
+	Name      string    `json:"name" validate:"required,min=3,max=10"`
+	Email     string    `json:"email" validate:"email"`
+	Role      string    `json:"role" validate:"enum=admin|member"`
+	Code      string    `json:"code" validate:"len=4"`
+	Phone     string    `json:"phone" validate:"regex=^\\+?[0-9]{3,}$"`
+	Age       int       `json:"age" validate:"min=18,max=150"`
+	Score     int       `json:"score" validate:"omitempty,min=10"`
+	Ratio     *float64  `json:"ratio" validate:"max=1"`
+	Tags      []string  `json:"tags" validate:"max=2"`
+	Address   address   `json:"address"`
+	Addresses []address `json:"addresses"`
+}
+
+func validUser() user {
+	return user{Name: "alice", Age: 20, Address: address{City: "Jakarta"}}
+}
+
+func TestValidate(t *testing.T) {
+	ratio := 2.0
+	zero := 0.0
+
+	tests := []struct {
+		name   string
+		modify func(u *user)
+		want   errors.Fields
+	}{
+		{"valid", func(u *user) {}, nil},
+		{"required", func(u *user) { u.Name = "" }, errors.Fields{"name": "is required"}},
+		{"min length", func(u *user) { u.Name = "al" }, errors.Fields{"name": "length must be at least 3"}},
+		{"max length counts runes", func(u *user) { u.Name = "ééééééééééé" }, errors.Fields{"name": "length must be at most 10"}},
+		{"empty optional string skips rules", func(u *user) { u.Email, u.Role, u.Code = "", "", "" }, nil},
+		{"email", func(u *user) { u.Email = "Alice <a@b.c>" }, errors.Fields{"email": "must be a valid email"}},
+		{"enum", func(u *user) { u.Role = "root" }, errors.Fields{"role": "must be one of admin, member"}},
+		{"len", func(u *user) { u.Code = "12345" }, errors.Fields{"code": "length must be 4"}},
+		{"regex with comma", func(u *user) { u.Phone = "12" }, errors.Fields{"phone": `must match pattern ^\+?[0-9]{3,}$`}},
+		{"min number", func(u *user) { u.Age = 17 }, errors.Fields{"age": "must be at least 18"}},
+		{"zero number is still checked", func(u *user) { u.Age = 0 }, errors.Fields{"age": "must be at least 18"}},
+		{"omitempty skips zero number", func(u *user) { u.Score = 0 }, nil},
+		{"omitempty still checks non-zero", func(u *user) { u.Score = 5 }, errors.Fields{"score": "must be at least 10"}},
+		{"nil pointer skips rules", func(u *user) { u.Ratio = nil }, nil},
+		{"pointer", func(u *user) { u.Ratio = &ratio }, errors.Fields{"ratio": "must be at most 1"}},
+		{"pointer to zero", func(u *user) { u.Ratio = &zero }, nil},
+		{"slice length", func(u *user) { u.Tags = []string{"a", "b", "c"} }, errors.Fields{"tags": "length must be at most 2"}},
+		{"empty nested struct", func(u *user) { u.Address = address{} }, errors.Fields{"address.city": "is required"}},
+		{"slice of struct", func(u *user) { u.Addresses = []address{{City: "x"}, {}} }, errors.Fields{"addresses[1].city": "is required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := validUser()
+			tt.modify(&u)
+
+			err := Validate(&u)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			e, ok := err.(*errors.Error)
+			if !ok {
+				t.Fatalf("err = %v, want *errors.Error", err)
+			}
+
+			if e.Code != errors.ErrorBadRequest.Code {
+				t.Fatalf("code = %d, want %d", e.Code, errors.ErrorBadRequest.Code)
+			}
+
+			if !reflect.DeepEqual(e.Fields, tt.want) {
+				t.Fatalf("fields = %v, want %v", e.Fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	type payload struct {
+		Name string `validate:"unknown"`
+	}
+
+	err := Validate(payload{Name: "x"})
+	if _, ok := err.(*errors.Error); ok || err == nil {
+		t.Fatalf("err = %v, want plain error", err)
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("even", func(v reflect.Value, param string) string {
+		if v.Kind() == reflect.Int && v.Int()%2 != 0 {
+			return "must be even"
+		}
+		return ""
+	})
+
+	type payload struct {
+		N int `json:"n" validate:"even"`
+	}
+
+	if err := Validate(payload{N: 2}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	err := Validate(payload{N: 3})
+	if e, ok := err.(*errors.Error); !ok || e.Fields["n"] != "must be even" {
+		t.Fatalf("err = %v, want n must be even", err)
+	}
+}